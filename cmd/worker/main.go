@@ -3,21 +3,67 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"strconv"
 	"sync"
+	"syscall"
+	"time"
 
 	"org-worker/internal/config"
 	"org-worker/internal/domain"
+	"org-worker/internal/health"
+	"org-worker/internal/metrics"
+	"org-worker/internal/notify"
 	"org-worker/internal/processor/image"
 	"org-worker/internal/processor/report"
+	"org-worker/internal/progress"
 	"org-worker/internal/queue"
 	"org-worker/internal/repository"
+	"org-worker/internal/retry"
+	"org-worker/internal/scheduler"
+	"org-worker/internal/storage"
+	"org-worker/internal/task"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/joho/godotenv"
 )
 
+const (
+	taskQueue = "task_queue"
+
+	// cancelChannel carries the tracking document ID of a job to stop.
+	cancelChannel = taskQueue + ":cancel"
+
+	// eventsChannel receives a notify.Event whenever a job finishes for good.
+	eventsChannel = taskQueue + ":events"
+
+	// lookupRetryDelay is used when a job fails before its handler could load
+	// the tracking document and apply the task's retry policy.
+	lookupRetryDelay = 30 * time.Second
+
+	// poolBusyDelay is how long a job waits in the delayed set when its
+	// concurrency pool is full. It doubles each time the same job finds the
+	// pool full again, up to poolBusyMaxDelay.
+	poolBusyDelay    = 2 * time.Second
+	poolBusyMaxDelay = 30 * time.Second
+
+	// leaseMargin is added to the task timeout so a claim outlives the job
+	// that holds it.
+	leaseMargin = time.Minute
+
+	// cancelGrace is how long jobs cancelled by the shutdown deadline get to
+	// return before they are re-queued.
+	cancelGrace = 10 * time.Second
+
+	// webhookGrace is how long pending webhook deliveries get to finish once
+	// the jobs have drained.
+	webhookGrace = 10 * time.Second
+)
+
 func main() {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
@@ -28,11 +74,52 @@ func main() {
 	reportRepo := repository.NewReportRepository(mongoDB)
 	imageJobRepo := repository.NewImageJobRepository(mongoDB)
 
-	ctx := context.Background()
-	storageProvider := config.InitStorageProvider(ctx, logger)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Jobs run on their own context so a shutdown signal only stops the main
+	// loop; in-flight work is cancelled once the grace period runs out.
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	storageProvider := config.InitStorageProvider(jobCtx, logger)
+	lanes := config.GetQueueLanes(taskQueue)
+	consumer := config.InitQueueConsumer(jobCtx, redisClient, logger, taskQueue, lanes)
+
+	if dir := config.GetFontDir(); dir != "" {
+		families, err := report.LoadFonts(dir)
+		if err != nil {
+			logger.Warn("Some report fonts could not be loaded", "dir", dir, "err", err)
+		}
+		logger.Info("Loaded report fonts", "dir", dir, "families", families)
+	}
+	report.FontFallback = config.GetFontFallback()
+
+	registry := task.NewRegistry()
+	report.NewReportHandler(reportRepo, storageProvider, config.GetRetryPolicy(report.TaskType), config.InitMailer(logger)).Register(registry)
+	image.NewImageHandler(imageJobRepo, storageProvider, config.GetRetryPolicy(image.TaskType)).Register(registry)
 
-	reportHandler := report.NewReportHandler(reportRepo, storageProvider)
-	imageHandler := image.NewImageHandler(imageJobRepo, storageProvider)
+	cancels := task.NewCancelWatcher(config.GetCancelPollInterval())
+	progress.MinInterval = config.GetProgressInterval()
+
+	go queue.RunDelayedMover(jobCtx, redisClient, logger, taskQueue)
+	if lanes.Routed() {
+		go lanes.RunRouter(jobCtx, redisClient, logger)
+	}
+	go cancels.Listen(jobCtx, redisClient, cancelChannel, logger)
+
+	webhookPolicy, webhookTimeout := config.GetWebhookPolicy()
+	runner := &jobRunner{
+		registry: registry,
+		cancels:  cancels,
+		notifier: notify.NewNotifier(redisClient, eventsChannel, os.Getenv("WEBHOOK_SECRET"), webhookPolicy, webhookTimeout),
+		owner:    fmt.Sprintf("%s-%d", config.GetWorkerID(), time.Now().UnixNano()),
+	}
+
+	if interval := config.GetSchedulerInterval(); interval > 0 {
+		scheduleRepo := repository.NewScheduleRepository(mongoDB)
+		go scheduler.New(scheduleRepo, redisClient, taskQueue, runner.owner, interval).Run(ctx, logger)
+	}
 
 	maxConcurrency := 10
 	if val := os.Getenv("MAX_CONCURRENCY"); val != "" {
@@ -40,68 +127,469 @@ func main() {
 			maxConcurrency = parsed
 		}
 	}
+	shutdownTimeout := config.GetShutdownTimeout()
 
 	sem := make(chan struct{}, maxConcurrency)
+	metrics.RegisterConcurrency(func() int { return len(sem) }, maxConcurrency)
+	pools := task.NewPools(config.GetConcurrencyLimits())
+	for _, key := range pools.Keys() {
+		metrics.RegisterPool(key, func() int { return pools.InUse(key) }, pools.Limit(key))
+	}
+	depthLists := []string{taskQueue, queue.DeadKey(taskQueue)}
+	if lanes.Routed() {
+		depthLists = append(depthLists, lanes.Keys()...)
+	}
+	metrics.RegisterQueueDepth(redisClient, depthLists, []string{queue.DelayedKey(taskQueue)})
+
+	checker := health.NewChecker(config.GetLoopMaxAge())
+	checker.AddCheck("mongo", func(ctx context.Context) error {
+		return mongoDB.Client().Ping(ctx, nil)
+	})
+	checker.AddCheck("redis", func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
+	if storageChecker, ok := storageProvider.(storage.HealthChecker); ok {
+		checker.AddCheck("storage", storageChecker.Ping)
+	}
+	if addr := config.GetHTTPAddr(); addr != "" {
+		mux := metrics.NewMux()
+		checker.Register(mux)
+		go metrics.Serve(jobCtx, addr, mux, logger)
+	}
 	var wg sync.WaitGroup
+	inFlight := newInFlightJobs()
 
-	logger.Info("Worker is running with concurrency limit:", "limit", maxConcurrency)
+	logger.Info("Worker is running with concurrency limit:", "limit", maxConcurrency, "pools", pools.Keys(), "taskTypes", registry.TaskTypes())
 
+loop:
 	for {
-		result, err := queue.BLPop(redisClient, ctx, "task_queue")
+		data, err := consumer.Pop(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
 			logger.Error("Error reading from Redis queue", "err", err)
 			continue
 		}
-		if len(result) < 2 {
+		checker.LoopAlive()
+		if data == "" {
+			if ctx.Err() != nil {
+				break
+			}
 			continue
 		}
 
-		sem <- struct{}{}
+		if !acquireSlot(ctx, sem, checker) {
+			// Popped while waiting for a free slot; hand it back untouched.
+			requeueJob(consumer, logger, registry, runner.owner, data)
+			break loop
+		}
 
 		wg.Add(1)
+		jobKey := inFlight.add(data)
 
 		go func(data string) {
 			defer wg.Done()
 			defer func() { <-sem }()
+			// Working out the pools may read the tracking document, so it
+			// happens here rather than in the loop popping jobs.
+			releasePool, ok := acquirePool(jobCtx, pools, registry, data)
+			if !ok {
+				// Past the shutdown deadline the job is re-queued there.
+				if jobCtx.Err() == nil {
+					inFlight.remove(jobKey)
+					deferJob(jobCtx, redisClient, consumer, logger, data)
+				}
+				return
+			}
+			defer releasePool()
+			defer inFlight.remove(jobKey)
 
-			var job domain.Job
-			if err := json.Unmarshal([]byte(data), &job); err != nil {
-				logger.Error("Failed to unmarshal job", "err", err)
+			err := runner.run(jobCtx, logger, data)
+			// A job cancelled by the shutdown deadline is re-queued there instead.
+			if jobCtx.Err() != nil {
 				return
 			}
+			settleJob(jobCtx, redisClient, logger, data, err)
+			if err := consumer.Ack(jobCtx, data); err != nil {
+				logger.Error("Failed to acknowledge job", "err", err)
+			}
+		}(data)
+	}
 
-			switch job.TaskType {
-			case "generate_report":
-				var payload domain.ReportJobPayload
-				if err := json.Unmarshal(job.Payload, &payload); err != nil {
-					logger.Error("Failed to unmarshal report payload", "err", err)
-					return
-				}
-				logger.Info("Received job", "reportID", payload.ReportID, "taskType", job.TaskType)
+	logger.Info("Shutdown requested, waiting for in-flight jobs", "count", inFlight.len(), "timeout", shutdownTimeout)
 
-				reportDoc, err := reportRepo.GetReportByID(ctx, payload.ReportID)
-				if err != nil {
-					logger.Error("Failed to get report", "err", err)
-					return
-				}
-				if err := reportHandler.HandleReportGeneration(ctx, logger, reportDoc); err != nil {
-					logger.Error("ERROR generate_report", "err", err)
-				}
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
 
-			case "process_image":
-				var payload domain.ImageJobPayload
-				if err := json.Unmarshal(job.Payload, &payload); err != nil {
-					logger.Error("Failed to unmarshal image payload", "err", err)
-					return
-				}
-				logger.Info("Starting job processing", "imageJobID", payload.ImageJobID)
+	select {
+	case <-drained:
+		logger.Info("All in-flight jobs finished, worker stopped")
+	case <-time.After(shutdownTimeout):
+		// Snapshot first so a job that returns because of the cancellation
+		// cannot slip out of the list before it is re-queued.
+		pending := inFlight.snapshot()
+		cancelJobs()
+		logger.Warn("Shutdown deadline exceeded, cancelling unfinished jobs", "count", len(pending))
+		// Let the handlers finish their last writes before the jobs are handed
+		// to another worker.
+		select {
+		case <-drained:
+		case <-time.After(cancelGrace):
+			logger.Warn("Cancelled jobs did not return in time", "count", inFlight.len(), "grace", cancelGrace)
+		}
+		logger.Warn("Re-queueing unfinished jobs", "count", len(pending))
+		for _, data := range pending {
+			requeueJob(consumer, logger, registry, runner.owner, data)
+		}
+	}
 
-				if err := imageHandler.HandleImageProcessing(ctx, logger, payload.ImageJobID); err != nil {
-					logger.Error("ERROR process_image", "err", err)
-				}
-			default:
-				logger.Warn("Unknown task type", "taskType", job.TaskType)
+	webhookCtx, cancelWebhooks := context.WithTimeout(context.Background(), webhookGrace)
+	defer cancelWebhooks()
+	if err := runner.notifier.Close(webhookCtx); err != nil {
+		logger.Warn("Gave up on pending webhook deliveries", "grace", webhookGrace, "err", err)
+	}
+
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelClose()
+	if err := consumer.Close(closeCtx); err != nil {
+		logger.Error("Failed to close queue consumer", "err", err)
+	}
+}
+
+// acquireSlot waits for a free concurrency slot. Waiting on busy slots is
+// not a wedged loop, so liveness keeps being reported meanwhile. It returns
+// false if ctx is cancelled first.
+func acquireSlot(ctx context.Context, sem chan struct{}, checker *health.Checker) bool {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case sem <- struct{}{}:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			checker.LoopAlive()
+		}
+	}
+}
+
+// acquirePool takes a slot in every concurrency pool the job belongs to
+// without waiting, so a busy pool never holds up jobs of other pools.
+func acquirePool(ctx context.Context, pools *task.Pools, registry *task.Registry, data string) (func(), bool) {
+	var job domain.Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return func() {}, true
+	}
+	return pools.TryAcquire(registry.PoolKeys(ctx, job))
+}
+
+// deferJob parks a job whose pool is full in the delayed set. It does not
+// count as an attempt; the job comes back through the queue after a delay
+// that grows with the number of times it was deferred.
+func deferJob(ctx context.Context, client *redis.Client, consumer queue.Consumer, logger *slog.Logger, data string) {
+	var job domain.Job
+	_ = json.Unmarshal([]byte(data), &job)
+	metrics.JobsTotal.WithLabelValues(job.TaskType, "throttled").Inc()
+
+	delay := poolBusyDelay << min(job.Deferrals, 4)
+	if delay >= poolBusyMaxDelay {
+		delay = poolBusyMaxDelay
+		logger.Warn("Job keeps finding its concurrency pool full", "taskType", job.TaskType, "deferrals", job.Deferrals, "delay", delay)
+	} else {
+		logger.Debug("Concurrency pool full, deferring job", "taskType", job.TaskType, "deferrals", job.Deferrals, "delay", delay)
+	}
+	deferred := data
+	job.Deferrals++
+	if b, err := json.Marshal(job); err == nil {
+		deferred = string(b)
+	}
+
+	deferCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := queue.Schedule(client, deferCtx, taskQueue, deferred, time.Now().Add(delay)); err != nil {
+		logger.Error("Failed to defer job for a busy pool, re-queueing", "taskType", job.TaskType, "err", err)
+		if err := consumer.Requeue(deferCtx, data); err != nil {
+			logger.Error("Failed to re-queue job", "err", err)
+		}
+		return
+	}
+	if err := consumer.Ack(deferCtx, data); err != nil {
+		logger.Error("Failed to acknowledge deferred job", "err", err)
+	}
+}
+
+// jobRunner holds what every job needs besides its payload.
+type jobRunner struct {
+	registry *task.Registry
+	cancels  *task.CancelWatcher
+	notifier *notify.Notifier
+	// owner identifies this worker process on claimed tracking documents.
+	owner string
+}
+
+func (r *jobRunner) run(ctx context.Context, logger *slog.Logger, data string) (err error) {
+	taskType, outcome := "unknown", ""
+	var (
+		handler task.TaskHandler
+		payload any
+	)
+	defer func() {
+		if outcome == "" {
+			var scheduled *retry.ScheduledError
+			outcome = "failed"
+			if errors.As(err, &scheduled) {
+				outcome = "retried"
 			}
-		}(result[1])
+		}
+		metrics.JobsTotal.WithLabelValues(taskType, outcome).Inc()
+		if handler != nil && isFinal(outcome, err) && ctx.Err() == nil {
+			r.notify(ctx, logger, taskType, handler, payload, outcome, err)
+		}
+	}()
+
+	var job domain.Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return retry.Permanent(fmt.Errorf("failed to unmarshal job: %w", err))
+	}
+	handler, payload, err = r.registry.Decode(job)
+	if err != nil {
+		return err
+	}
+	taskType = job.TaskType
+	documentID := handler.DocumentID(payload)
+	logger = logger.With("taskType", job.TaskType, "documentID", documentID)
+
+	if status, err := handler.Status(ctx, payload); err == nil && task.IsCancelStatus(status) {
+		logger.Info("Job was cancelled before it started")
+		markStatus(ctx, logger, handler, payload, task.StatusCancelled, "")
+		outcome = "cancelled"
+		return nil
+	}
+
+	timeout := config.GetTaskTimeout(job.TaskType)
+	if err := handler.Claim(ctx, payload, r.owner, time.Now().Add(timeout+leaseMargin)); err != nil {
+		var held *domain.LeaseHeldError
+		switch {
+		case errors.Is(err, domain.ErrNotClaimable):
+			logger.Info("Skipping job that is already handled", "reason", err)
+			outcome = "skipped"
+			return nil
+		case errors.As(err, &held):
+			// Either a duplicate still running elsewhere or a crashed worker;
+			// look again once the lease runs out.
+			delay := max(time.Until(held.Until)+time.Second, time.Second)
+			outcome = "deferred"
+			return &retry.ScheduledError{Err: err, Delay: delay}
+		}
+		return err
+	}
+	ctx = task.WithOwner(ctx, r.owner)
+
+	taskCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	taskCtx, release := r.cancels.Watch(taskCtx, documentID, func(c context.Context) (string, error) {
+		return handler.Status(c, payload)
+	})
+	defer release()
+
+	start := time.Now()
+	err = handler.Handle(taskCtx, logger, payload)
+	metrics.JobDuration.WithLabelValues(taskType).Observe(time.Since(start).Seconds())
+	if err == nil {
+		outcome = "completed"
+		return nil
+	}
+	// Whatever the handler decided in these two cases (including a scheduled
+	// retry) was written with a dead context, so the outcome is set here.
+	switch {
+	case errors.Is(context.Cause(taskCtx), task.ErrCancelled):
+		logger.Info("Job cancelled on request", "err", err)
+		markStatus(ctx, logger, handler, payload, task.StatusCancelled, "")
+		outcome = "cancelled"
+		return nil
+	case errors.Is(taskCtx.Err(), context.DeadlineExceeded):
+		errMsg := fmt.Sprintf("job exceeded its %s deadline", timeout)
+		logger.Warn("Job timed out", "timeout", timeout, "err", err)
+		markStatus(ctx, logger, handler, payload, "timed_out", errMsg)
+		outcome = "timed_out"
+		return retry.Permanent(errors.New(errMsg))
+	case errors.Is(err, domain.ErrLeaseLost):
+		// Cancelled before the watcher noticed, or re-claimed elsewhere after
+		// the lease ran out; either way nothing of this run is kept.
+		if status, err := handler.Status(ctx, payload); err == nil && task.IsCancelStatus(status) {
+			logger.Info("Job cancelled on request")
+			markStatus(ctx, logger, handler, payload, task.StatusCancelled, "")
+			outcome = "cancelled"
+			return nil
+		}
+		logger.Warn("Lost the claim on the job, leaving it to its new owner")
+		outcome = "lost"
+		return nil
+	}
+	return err
+}
+
+// isFinal reports whether a job will not run again after this outcome, the
+// same way settleJob decides between a retry and the dead-letter list.
+func isFinal(outcome string, err error) bool {
+	switch outcome {
+	case "completed", "cancelled", "timed_out":
+		return true
+	case "failed":
+		var exhausted *retry.ExhaustedError
+		return errors.As(err, &exhausted) || !retry.IsRetryable(err)
+	}
+	return false
+}
+
+// notify sends the completion event for a finished job, filled in from its
+// tracking document when that can still be read.
+func (r *jobRunner) notify(ctx context.Context, logger *slog.Logger, taskType string, handler task.TaskHandler, payload any, outcome string, err error) {
+	event := notify.Event{
+		Event:      notify.EventName(outcome),
+		TaskType:   taskType,
+		DocumentID: handler.DocumentID(payload),
+		Status:     outcome,
+		Timestamp:  time.Now(),
+	}
+	if err != nil {
+		event.ErrorMsg = err.Error()
+	}
+	readCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	result, readErr := handler.Result(readCtx, payload)
+	if readErr != nil {
+		logger.Warn("Failed to read job result for completion event", "err", readErr)
+	} else {
+		event.Status = result.Status
+		event.ResultURL = result.ResultURL
+		event.ResultURLs = result.ResultURLs
+		event.Attempts = result.Attempts
+		if result.ErrorMsg != "" {
+			event.ErrorMsg = result.ErrorMsg
+		}
+	}
+	r.notifier.Notify(ctx, logger, event, result.CallbackURL)
+}
+
+func markStatus(ctx context.Context, logger *slog.Logger, handler task.TaskHandler, payload any, status, errMsg string) {
+	markCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := handler.MarkStatus(markCtx, payload, status, errMsg); err != nil {
+		logger.Error("Failed to update job status", "status", status, "err", err)
+	}
+}
+
+// settleJob decides what happens to a job after its handler returned:
+// scheduled retries go to the delayed set, everything that cannot succeed
+// goes to the dead-letter list.
+func settleJob(ctx context.Context, client *redis.Client, logger *slog.Logger, data string, err error) {
+	if err == nil {
+		return
+	}
+
+	var (
+		scheduled *retry.ScheduledError
+		exhausted *retry.ExhaustedError
+		delay     time.Duration
+	)
+	switch {
+	case errors.As(err, &scheduled):
+		logger.Warn("Job failed, retry scheduled", "attempt", scheduled.Attempt, "delay", scheduled.Delay, "err", scheduled.Err)
+		delay = scheduled.Delay
+	case errors.As(err, &exhausted):
+		logger.Error("Job failed, moving to dead-letter queue", "attempt", exhausted.Attempt, "err", exhausted.Err)
+		if err := queue.DeadLetter(client, ctx, taskQueue, data, exhausted.Err.Error(), exhausted.Attempt); err != nil {
+			logger.Error("Failed to dead-letter job", "err", err)
+		}
+		return
+	case retry.IsRetryable(err):
+		// The handler never reached its tracking document, e.g. MongoDB was
+		// unreachable, so there is no attempt counter to consult.
+		logger.Warn("Job could not start, retry scheduled", "delay", lookupRetryDelay, "err", err)
+		delay = lookupRetryDelay
+	default:
+		logger.Error("Job failed permanently, moving to dead-letter queue", "err", err)
+		if err := queue.DeadLetter(client, ctx, taskQueue, data, err.Error(), 0); err != nil {
+			logger.Error("Failed to dead-letter job", "err", err)
+		}
+		return
+	}
+
+	if err := queue.Schedule(client, ctx, taskQueue, data, time.Now().Add(delay)); err != nil {
+		logger.Error("Failed to schedule job retry", "err", err)
+	}
+}
+
+// requeueJob pushes an unfinished job back onto the queue. When Redis is not
+// reachable the tracking document is marked "interrupted" instead so it does
+// not stay in "pending" forever.
+func requeueJob(consumer queue.Consumer, logger *slog.Logger, registry *task.Registry, owner, data string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var job domain.Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		_ = consumer.Requeue(ctx, data)
+		return
+	}
+
+	if err := consumer.Requeue(ctx, data); err != nil {
+		logger.Error("Failed to re-queue job, marking as interrupted", "err", err)
+		if err := registry.MarkStatus(task.WithOwner(ctx, owner), job, "interrupted", "worker shut down before the job finished"); err != nil {
+			logger.Error("Failed to mark job as interrupted", "err", err)
+		}
+		return
+	}
+	// Release the claim so the next delivery does not wait for the lease.
+	if err := registry.Release(ctx, job, owner); err != nil {
+		logger.Error("Failed to release job claim", "err", err)
+	}
+}
+
+// inFlightJobs remembers the raw payload of every running job so they can be
+// handed back to the queue if the shutdown deadline passes.
+type inFlightJobs struct {
+	mu   sync.Mutex
+	next uint64
+	jobs map[uint64]string
+}
+
+func newInFlightJobs() *inFlightJobs {
+	return &inFlightJobs{jobs: make(map[uint64]string)}
+}
+
+func (f *inFlightJobs) add(data string) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next++
+	f.jobs[f.next] = data
+	return f.next
+}
+
+func (f *inFlightJobs) remove(key uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.jobs, key)
+}
+
+func (f *inFlightJobs) len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.jobs)
+}
+
+func (f *inFlightJobs) snapshot() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := make([]string, 0, len(f.jobs))
+	for _, data := range f.jobs {
+		result = append(result, data)
 	}
+	return result
 }
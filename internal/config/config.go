@@ -4,8 +4,15 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"org-worker/internal/domain"
+	"org-worker/internal/mail"
+	"org-worker/internal/metrics"
+	"org-worker/internal/queue"
+	"org-worker/internal/retry"
 	"org-worker/internal/storage"
 
 	"github.com/go-redis/redis/v8"
@@ -29,6 +36,223 @@ func GetOrgName() string {
 	return name
 }
 
+// GetBranding returns the organization-wide report branding from ORG_NAME,
+// BRAND_LOGO, BRAND_COLOR_PRIMARY, BRAND_COLOR_SECONDARY,
+// BRAND_COLOR_BACKGROUND, BRAND_COLOR_TEXT, BRAND_COLOR_MUTED, BRAND_FONT,
+// BRAND_FOOTER and BRAND_CONTACT. Unset values use the built-in theme.
+func GetBranding() domain.Branding {
+	return domain.Branding{
+		OrgName: GetOrgName(),
+		Logo:    os.Getenv("BRAND_LOGO"),
+		Colors: domain.BrandingColors{
+			Primary:    os.Getenv("BRAND_COLOR_PRIMARY"),
+			Secondary:  os.Getenv("BRAND_COLOR_SECONDARY"),
+			Background: os.Getenv("BRAND_COLOR_BACKGROUND"),
+			Text:       os.Getenv("BRAND_COLOR_TEXT"),
+			Muted:      os.Getenv("BRAND_COLOR_MUTED"),
+		},
+		Font:       os.Getenv("BRAND_FONT"),
+		FooterText: os.Getenv("BRAND_FOOTER"),
+		Contact:    os.Getenv("BRAND_CONTACT"),
+	}
+}
+
+// GetFontDir is the directory with the TrueType fonts (FONT_DIR) that
+// reports can use besides Maroto's built-in fonts. Empty means none.
+func GetFontDir() string {
+	return os.Getenv("FONT_DIR")
+}
+
+// GetFontFallback reads FONT_FALLBACK, a comma-separated list of font
+// families tried in order for text the theme's font cannot draw.
+func GetFontFallback() []string {
+	var families []string
+	for _, family := range strings.Split(os.Getenv("FONT_FALLBACK"), ",") {
+		if family = strings.ToLower(strings.TrimSpace(family)); family != "" {
+			families = append(families, family)
+		}
+	}
+	return families
+}
+
+// GetShutdownTimeout returns how long the worker waits for in-flight jobs
+// after SIGINT/SIGTERM before re-queueing whatever is still running.
+func GetShutdownTimeout() time.Duration {
+	if val := os.Getenv("SHUTDOWN_TIMEOUT"); val != "" {
+		if parsed, err := time.ParseDuration(val); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return 30 * time.Second
+}
+
+// GetWorkerID identifies this worker's processing list in reliable queue
+// mode. It defaults to the hostname, which compose keeps stable across
+// restarts so a restarted container recovers its own unfinished jobs.
+func GetWorkerID() string {
+	if id := os.Getenv("WORKER_ID"); id != "" {
+		return id
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return "worker"
+}
+
+var defaultRetryPolicies = map[string]retry.Policy{
+	"generate_report": {MaxAttempts: 3, BaseDelay: 30 * time.Second, MaxDelay: 10 * time.Minute},
+	"process_image":   {MaxAttempts: 5, BaseDelay: 5 * time.Second, MaxDelay: 5 * time.Minute},
+}
+
+// GetRetryPolicy returns the retry policy for a task type. Every field can be
+// overridden globally (RETRY_MAX_ATTEMPTS, RETRY_BASE_DELAY, RETRY_MAX_DELAY)
+// or per task type by appending the upper-cased type, e.g.
+// RETRY_MAX_ATTEMPTS_GENERATE_REPORT.
+func GetRetryPolicy(taskType string) retry.Policy {
+	policy, ok := defaultRetryPolicies[taskType]
+	if !ok {
+		policy = retry.Policy{MaxAttempts: 3, BaseDelay: 10 * time.Second, MaxDelay: 5 * time.Minute}
+	}
+	suffix := "_" + strings.ToUpper(taskType)
+	for _, key := range []string{"RETRY_MAX_ATTEMPTS", "RETRY_MAX_ATTEMPTS" + suffix} {
+		if parsed, err := strconv.Atoi(os.Getenv(key)); err == nil && parsed > 0 {
+			policy.MaxAttempts = parsed
+		}
+	}
+	for _, key := range []string{"RETRY_BASE_DELAY", "RETRY_BASE_DELAY" + suffix} {
+		if parsed, err := time.ParseDuration(os.Getenv(key)); err == nil && parsed > 0 {
+			policy.BaseDelay = parsed
+		}
+	}
+	for _, key := range []string{"RETRY_MAX_DELAY", "RETRY_MAX_DELAY" + suffix} {
+		if parsed, err := time.ParseDuration(os.Getenv(key)); err == nil && parsed > 0 {
+			policy.MaxDelay = parsed
+		}
+	}
+	return policy
+}
+
+var defaultTaskTimeouts = map[string]time.Duration{
+	"generate_report": 10 * time.Minute,
+	"process_image":   2 * time.Minute,
+}
+
+// GetTaskTimeout returns the deadline applied to a single job of taskType.
+// TASK_TIMEOUT overrides every type, TASK_TIMEOUT_<TYPE> a single one.
+func GetTaskTimeout(taskType string) time.Duration {
+	timeout, ok := defaultTaskTimeouts[taskType]
+	if !ok {
+		timeout = 5 * time.Minute
+	}
+	for _, key := range []string{"TASK_TIMEOUT", "TASK_TIMEOUT_" + strings.ToUpper(taskType)} {
+		if parsed, err := time.ParseDuration(os.Getenv(key)); err == nil && parsed > 0 {
+			timeout = parsed
+		}
+	}
+	return timeout
+}
+
+// GetConcurrencyLimits reads per-pool job limits from CONCURRENCY_LIMITS,
+// e.g. "generate_report:3,generate_report/financial_summary:1,process_image:8".
+// Entries that do not parse are skipped. Per-report-type pools match the
+// payload's reportType; producers should always set it, since a job without
+// one costs a lookup of its report document in the worker's main loop.
+func GetConcurrencyLimits() map[string]int {
+	limits := make(map[string]int)
+	for _, part := range strings.Split(os.Getenv("CONCURRENCY_LIMITS"), ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			continue
+		}
+		if parsed, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && parsed > 0 {
+			limits[strings.TrimSpace(key)] = parsed
+		}
+	}
+	return limits
+}
+
+// GetSchedulerInterval is how often the scheduler checks report_schedules.
+// SCHEDULER_INTERVAL=0 turns the scheduler off on this worker.
+func GetSchedulerInterval() time.Duration {
+	val := os.Getenv("SCHEDULER_INTERVAL")
+	if val == "" {
+		return 30 * time.Second
+	}
+	parsed, err := time.ParseDuration(val)
+	if err != nil || parsed < 0 {
+		return 30 * time.Second
+	}
+	return parsed
+}
+
+// GetWebhookPolicy returns how callbackURL deliveries are retried
+// (WEBHOOK_MAX_ATTEMPTS, default 5) and the per-request timeout
+// (WEBHOOK_TIMEOUT, default 10s).
+func GetWebhookPolicy() (retry.Policy, time.Duration) {
+	policy := retry.Policy{MaxAttempts: 5, BaseDelay: 2 * time.Second, MaxDelay: time.Minute}
+	if parsed, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && parsed > 0 {
+		policy.MaxAttempts = parsed
+	}
+	timeout := 10 * time.Second
+	if parsed, err := time.ParseDuration(os.Getenv("WEBHOOK_TIMEOUT")); err == nil && parsed > 0 {
+		timeout = parsed
+	}
+	return policy, timeout
+}
+
+// InitMailer configures SMTP delivery from SMTP_HOST, SMTP_PORT (default
+// 587), SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM. It returns nil when
+// SMTP_HOST is not set, which turns email delivery off.
+func InitMailer(logger *slog.Logger) *mail.Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil
+	}
+	port := 587
+	if parsed, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil && parsed > 0 {
+		port = parsed
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USERNAME")
+	}
+	logger.Info("Email delivery enabled", "host", host, "port", port, "from", from)
+	return mail.NewMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+}
+
+// GetCancelPollInterval is how often a running job re-reads its tracking
+// document to notice a cancel_requested status.
+func GetCancelPollInterval() time.Duration {
+	if parsed, err := time.ParseDuration(os.Getenv("CANCEL_POLL_INTERVAL")); err == nil && parsed > 0 {
+		return parsed
+	}
+	return 5 * time.Second
+}
+
+// GetProgressInterval is the minimum time between two progress writes for
+// the same job stage.
+func GetProgressInterval() time.Duration {
+	if parsed, err := time.ParseDuration(os.Getenv("PROGRESS_MIN_INTERVAL")); err == nil && parsed > 0 {
+		return parsed
+	}
+	return 2 * time.Second
+}
+
+// GetHTTPAddr is the listen address for the worker's HTTP endpoints
+// (e.g. ":9090"). An empty value disables the listener.
+func GetHTTPAddr() string {
+	return os.Getenv("HTTP_ADDR")
+}
+
+// GetLoopMaxAge is how long the main loop may go without completing a queue
+// read before /healthz reports the worker as wedged.
+func GetLoopMaxAge() time.Duration {
+	if parsed, err := time.ParseDuration(os.Getenv("HEALTH_LOOP_MAX_AGE")); err == nil && parsed > 0 {
+		return parsed
+	}
+	return time.Minute
+}
+
 func InitRedis() *redis.Client {
 	ctx := context.Background()
 	redisAddr := os.Getenv("REDIS_URI")
@@ -82,8 +306,48 @@ func InitStorageProvider(ctx context.Context, logger *slog.Logger) storage.Stora
 			logger.Error("Failed to initialize R2 Storage", "err", err)
 			os.Exit(1)
 		}
-		return storageProvider
+		return metrics.InstrumentStorage(storageProvider, "r2")
 	}
 	logger.Info("Using Local File Storage")
-	return storage.NewLocalStorage("./reports")
+	local := storage.NewLocalStorage("./reports")
+	// Created here once; the readiness check only looks at the folder.
+	if err := os.MkdirAll(local.BasePath, 0755); err != nil {
+		logger.Error("Failed to create local storage folder", "path", local.BasePath, "err", err)
+		os.Exit(1)
+	}
+	return metrics.InstrumentStorage(local, "local")
+}
+
+// GetQueueLanes reads the priority lanes from QUEUE_LANES (e.g.
+// "high:6,default:3,bulk:1") and the per-task-type defaults from TASK_LANES
+// (e.g. "process_image:bulk"). Without QUEUE_LANES the queue is consumed
+// directly as before.
+func GetQueueLanes(queueName string) *queue.Lanes {
+	lanes, err := queue.ParseLanes(queueName, os.Getenv("QUEUE_LANES"), os.Getenv("TASK_LANES"))
+	if err != nil {
+		slog.Error("Invalid queue lane configuration", "err", err)
+		os.Exit(1)
+	}
+	return lanes
+}
+
+func InitQueueConsumer(ctx context.Context, client *redis.Client, logger *slog.Logger, queueName string, lanes *queue.Lanes) queue.Consumer {
+	if os.Getenv("QUEUE_MODE") != "reliable" {
+		logger.Info("Using simple queue mode", "queue", queueName, "lanes", lanes.Keys())
+		return queue.NewSimpleQueue(client, queueName, lanes)
+	}
+	visibilityTimeout := 60 * time.Second
+	if val := os.Getenv("QUEUE_VISIBILITY_TIMEOUT"); val != "" {
+		if parsed, err := time.ParseDuration(val); err == nil && parsed > 0 {
+			visibilityTimeout = parsed
+		}
+	}
+	workerID := GetWorkerID()
+	consumer := queue.NewReliableQueue(client, queueName, lanes, workerID, visibilityTimeout)
+	if err := consumer.Start(ctx, logger); err != nil {
+		logger.Error("Failed to start reliable queue", "err", err)
+		os.Exit(1)
+	}
+	logger.Info("Using reliable queue mode", "queue", queueName, "lanes", lanes.Keys(), "workerID", workerID, "visibilityTimeout", visibilityTimeout)
+	return consumer
 }
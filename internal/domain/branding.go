@@ -0,0 +1,52 @@
+package domain
+
+// Branding is the look of the generated reports. The worker starts from the
+// BRAND_* environment variables, then applies the branding document without
+// a community (the organization default) and finally the one whose
+// Community matches the report's community_name. Empty fields keep the
+// value from the level below.
+type Branding struct {
+	Community string `bson:"community,omitempty"`
+	OrgName   string `bson:"orgName,omitempty"`
+	// Logo is an http(s) URL, e.g. a public storage URL, or a local file path.
+	Logo   string         `bson:"logo,omitempty"`
+	Colors BrandingColors `bson:"colors,omitempty"`
+	// Font is a PDF font family such as "arial", "helvetica" or "courier".
+	Font       string `bson:"font,omitempty"`
+	FooterText string `bson:"footerText,omitempty"`
+	Contact    string `bson:"contact,omitempty"`
+}
+
+// BrandingColors holds hex colors such as "#1E3A8A".
+type BrandingColors struct {
+	Primary    string `bson:"primary,omitempty"`
+	Secondary  string `bson:"secondary,omitempty"`
+	Background string `bson:"background,omitempty"`
+	Text       string `bson:"text,omitempty"`
+	Muted      string `bson:"muted,omitempty"`
+}
+
+// Merge returns b with every non-empty field of over applied on top.
+func (b Branding) Merge(over Branding) Branding {
+	pick := func(base, over string) string {
+		if over != "" {
+			return over
+		}
+		return base
+	}
+	return Branding{
+		Community: pick(b.Community, over.Community),
+		OrgName:   pick(b.OrgName, over.OrgName),
+		Logo:      pick(b.Logo, over.Logo),
+		Colors: BrandingColors{
+			Primary:    pick(b.Colors.Primary, over.Colors.Primary),
+			Secondary:  pick(b.Colors.Secondary, over.Colors.Secondary),
+			Background: pick(b.Colors.Background, over.Colors.Background),
+			Text:       pick(b.Colors.Text, over.Colors.Text),
+			Muted:      pick(b.Colors.Muted, over.Colors.Muted),
+		},
+		Font:       pick(b.Font, over.Font),
+		FooterText: pick(b.FooterText, over.FooterText),
+		Contact:    pick(b.Contact, over.Contact),
+	}
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotClaimable means the tracking document is in a state that must not be
+// processed again, e.g. it is already completed and the job was not forced.
+var ErrNotClaimable = errors.New("job is not claimable")
+
+// ErrLeaseLost means the worker no longer owns the tracking document it
+// tried to write: its lease expired and another worker claimed the job, or
+// the job was cancelled while it ran.
+var ErrLeaseLost = errors.New("job lease lost")
+
+// LeaseHeldError means another worker is still processing the document and
+// its lease has not expired yet.
+type LeaseHeldError struct {
+	Owner string
+	Until time.Time
+}
+
+func (e *LeaseHeldError) Error() string {
+	return fmt.Sprintf("job is leased by %s until %s", e.Owner, e.Until.Format(time.RFC3339))
+}
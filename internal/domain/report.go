@@ -25,12 +25,39 @@ type CommunityActivityData struct {
 	ActiveMemberCount int64         `json:"activeMemberCount"`
 	EventsHeldCount   int           `json:"eventsHeldCount"`
 	EventDetails      []EventDetail `json:"eventDetails"`
+	// TrendInterval is "week" or "month", the length of each Trend bucket.
+	TrendInterval string           `json:"trendInterval"`
+	Trend         []ActivityBucket `json:"trend"`
+	// Comparison is set when the report was requested with a compare filter.
+	Comparison *ActivityComparison `json:"comparison,omitempty"`
+}
+
+// ActivityBucket counts the community's activity in one week or month of
+// the report period. Start is the first day of the week (Monday) or month.
+type ActivityBucket struct {
+	Start      time.Time `json:"start"`
+	EventsHeld int       `json:"eventsHeld"`
+	Attendance int       `json:"attendance"`
+	NewMembers int       `json:"newMembers"`
+}
+
+// ActivityComparison holds the summary metrics of the period a community
+// activity report is compared with.
+type ActivityComparison struct {
+	Mode              string    `json:"mode"`
+	StartDate         time.Time `json:"startDate"`
+	EndDate           time.Time `json:"endDate"`
+	NewMemberCount    int64     `json:"newMemberCount"`
+	ActiveMemberCount int64     `json:"activeMemberCount"`
+	EventsHeldCount   int       `json:"eventsHeldCount"`
 }
 
 type ReportJobPayload struct {
 	ReportID   string                 `json:"reportID"`
 	ReportType string                 `json:"reportType"`
 	Filters    map[string]interface{} `json:"filters"`
+	// Force regenerates a report even if it already completed or failed.
+	Force bool `json:"force,omitempty"`
 }
 
 type DemographicStat struct {
@@ -57,6 +84,17 @@ type ProgramImpactData struct {
 	EndDate       time.Time         `json:"endDate"`
 	Stats         []MilestoneStat   `json:"stats"`
 	Highlights    []ImpactHighlight `json:"highlights,omitempty"`
+	// Comparison is set when the report was requested with a compare filter.
+	Comparison *ImpactComparison `json:"comparison,omitempty"`
+}
+
+// ImpactComparison holds the milestone counts of the period a program
+// impact report is compared with.
+type ImpactComparison struct {
+	Mode      string          `json:"mode"`
+	StartDate time.Time       `json:"startDate"`
+	EndDate   time.Time       `json:"endDate"`
+	Stats     []MilestoneStat `json:"stats"`
 }
 
 type FinancialStat struct {
@@ -80,6 +118,20 @@ type FinancialReportData struct {
 	ExpensesByCategory []FinancialStat `json:"expensesByCategory"`
 	IncomeBySource     []FinancialStat `json:"incomeBySource"`
 	TopDonations       []TopDonation   `json:"topDonations"`
+	// Comparison is set when the report was requested with a compare filter.
+	Comparison *FinancialComparison `json:"comparison,omitempty"`
+}
+
+// FinancialComparison holds the totals of the period a financial report is
+// compared with.
+type FinancialComparison struct {
+	Mode             string    `json:"mode"`
+	StartDate        time.Time `json:"startDate"`
+	EndDate          time.Time `json:"endDate"`
+	TotalIncome      float64   `json:"totalIncome"`
+	TotalInKindValue float64   `json:"totalInKindValue"`
+	TotalExpenses    float64   `json:"totalExpenses"`
+	NetIncome        float64   `json:"netIncome"`
 }
 
 type User struct {
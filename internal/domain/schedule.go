@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReportSchedule is a recurring report definition from report_schedules.
+// Filters is copied into every generated report; Period, when set, fills in
+// start_date and end_date relative to the time the schedule fired.
+type ReportSchedule struct {
+	ID         primitive.ObjectID     `bson:"_id"`
+	Name       string                 `bson:"name,omitempty"`
+	Cron       string                 `bson:"cron"`
+	Timezone   string                 `bson:"timezone,omitempty"`
+	ReportType string                 `bson:"reportType"`
+	Filters    map[string]interface{} `bson:"filters"`
+	Period     string                 `bson:"period,omitempty"`
+	Enabled    bool                   `bson:"enabled"`
+	// LastRunAt is the scheduled time of the last run, not when it happened.
+	LastRunAt    primitive.DateTime `bson:"lastRunAt,omitempty"`
+	LastReportID primitive.ObjectID `bson:"lastReportID,omitempty"`
+	LastError    string             `bson:"lastError,omitempty"`
+	CreatedAt    primitive.DateTime `bson:"createdAt,omitempty"`
+}
@@ -0,0 +1,116 @@
+package domain
+
+// ReportTemplate describes the PDF and HTML layout of a report type. The
+// built-in templates are embedded in the worker; documents in
+// report_templates with the same name replace them, and any other name can
+// be picked with the template field of a report.
+//
+// Texts are message formats ("%d Orang") translated through the report
+// catalog. Their Args are field paths into the report data using JSON
+// names ("startDate", "stats.level_up.count"), optionally followed by
+// filters: "|date", "|money", "|count", "|percent:<path>",
+// "|default:<text>" and "|milestone". Inside a list the current item is
+// searched first, and "#" is its 1-based position.
+type ReportTemplate struct {
+	Name       string            `bson:"name" json:"name"`
+	ReportType string            `bson:"reportType" json:"reportType"`
+	Title      string            `bson:"title" json:"title"`
+	Subtitle   TemplateText      `bson:"subtitle" json:"subtitle"`
+	Sections   []TemplateSection `bson:"sections" json:"sections"`
+}
+
+// TemplateText is a message format filled in from the report data.
+type TemplateText struct {
+	Format string   `bson:"format" json:"format"`
+	Args   []string `bson:"args,omitempty" json:"args,omitempty"`
+}
+
+// TemplateSection is one titled block of a report. Its parts are drawn in
+// the order cards, charts, lines, table, items.
+type TemplateSection struct {
+	Title string `bson:"title" json:"title"`
+	// When skips the whole section if the value at this path is zero or empty.
+	When string `bson:"when,omitempty" json:"when,omitempty"`
+	// EmptyIf shows Empty instead of the parts if the value at this path is
+	// zero or empty.
+	EmptyIf string `bson:"emptyIf,omitempty" json:"emptyIf,omitempty"`
+	Empty   string `bson:"empty,omitempty" json:"empty,omitempty"`
+	// ChartError is shown when none of the charts could be drawn.
+	ChartError string          `bson:"chartError,omitempty" json:"chartError,omitempty"`
+	Cards      []TemplateCard  `bson:"cards,omitempty" json:"cards,omitempty"`
+	Charts     []TemplateChart `bson:"charts,omitempty" json:"charts,omitempty"`
+	Lines      []TemplateLines `bson:"lines,omitempty" json:"lines,omitempty"`
+	Table      *TemplateTable  `bson:"table,omitempty" json:"table,omitempty"`
+	Items      *TemplateItems  `bson:"items,omitempty" json:"items,omitempty"`
+}
+
+// TemplateCard is one summary card.
+type TemplateCard struct {
+	Label  string          `bson:"label" json:"label"`
+	Value  TemplateText    `bson:"value" json:"value"`
+	Change *TemplateChange `bson:"change,omitempty" json:"change,omitempty"`
+}
+
+// TemplateChange shows on a card how the metric at Current moved since the
+// metric at Previous, usually a field of the report's comparison period
+// ("comparison.totalIncome"). It is left out when Previous is unavailable,
+// i.e. the report was not requested with a comparison.
+type TemplateChange struct {
+	Current  string `bson:"current" json:"current"`
+	Previous string `bson:"previous" json:"previous"`
+	// Money formats the difference as an amount.
+	Money bool `bson:"money,omitempty" json:"money,omitempty"`
+	// LowerIsBetter marks a decrease as the good direction, e.g. expenses.
+	LowerIsBetter bool `bson:"lowerIsBetter,omitempty" json:"lowerIsBetter,omitempty"`
+}
+
+// TemplateChart draws a "pie", "bar" or "line" chart of the list at Source.
+// Label and Value are evaluated per item and default to "id" and "count";
+// Total is the path pie percentages are taken of, the sum of the values if
+// unset. Title is drawn above bar and line charts; line charts span the full
+// width and follow the list order.
+type TemplateChart struct {
+	Kind   string `bson:"kind" json:"kind"`
+	Title  string `bson:"title,omitempty" json:"title,omitempty"`
+	Source string `bson:"source" json:"source"`
+	Label  string `bson:"label,omitempty" json:"label,omitempty"`
+	Value  string `bson:"value,omitempty" json:"value,omitempty"`
+	Total  string `bson:"total,omitempty" json:"total,omitempty"`
+}
+
+// TemplateLines writes Text once per item of Source, or once if Source is
+// empty. When skips the lines if the value at its path is zero, empty or
+// nil.
+type TemplateLines struct {
+	Source string       `bson:"source,omitempty" json:"source,omitempty"`
+	When   string       `bson:"when,omitempty" json:"when,omitempty"`
+	Text   TemplateText `bson:"text" json:"text"`
+}
+
+// TemplateTable writes one row per item of Source.
+type TemplateTable struct {
+	Source  string           `bson:"source" json:"source"`
+	Columns []TemplateColumn `bson:"columns" json:"columns"`
+}
+
+// TemplateColumn is a table column. Width is in grid units out of 12;
+// columns without one share what the others leave. Align is "left"
+// (default), "center" or "right".
+type TemplateColumn struct {
+	Header string       `bson:"header" json:"header"`
+	Value  TemplateText `bson:"value" json:"value"`
+	Width  int          `bson:"width,omitempty" json:"width,omitempty"`
+	Align  string       `bson:"align,omitempty" json:"align,omitempty"`
+}
+
+// TemplateItems writes a block per item of Source: a heading, a meta line,
+// the text at the Text path (TextEmpty when it is blank) and a gallery of
+// the photo URLs at the Photos path.
+type TemplateItems struct {
+	Source    string       `bson:"source" json:"source"`
+	Heading   TemplateText `bson:"heading" json:"heading"`
+	Meta      TemplateText `bson:"meta,omitempty" json:"meta,omitempty"`
+	Text      string       `bson:"text,omitempty" json:"text,omitempty"`
+	TextEmpty string       `bson:"textEmpty,omitempty" json:"textEmpty,omitempty"`
+	Photos    string       `bson:"photos,omitempty" json:"photos,omitempty"`
+}
@@ -10,21 +10,82 @@ import (
 type Job struct {
 	TaskType string          `json:"task_type"`
 	Payload  json.RawMessage `json:"payload"`
+	// Lane overrides the task type's default priority lane when lanes are
+	// configured. The router reads it straight from the raw job.
+	Lane string `json:"lane,omitempty"`
+	// Deferrals counts how often the job was put back because its
+	// concurrency pool was full; the wait grows with it.
+	Deferrals int `json:"deferrals,omitempty"`
 }
 
 type ImageJobPayload struct {
 	ImageJobID string `json:"imageJobID"`
+	// Force reprocesses a job even if it already completed or failed.
+	Force bool `json:"force,omitempty"`
+}
+
+// Progress is the sub-document handlers update while a job runs so the
+// frontend can show more than "pending".
+type Progress struct {
+	Stage     string    `bson:"stage" json:"stage"`
+	Percent   int       `bson:"percent" json:"percent"`
+	Step      string    `bson:"step" json:"step"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// JobResult is what a tracking document says about a job once it stopped
+// running, as reported in completion events.
+type JobResult struct {
+	Status      string
+	ResultURL   string
+	ResultURLs  map[string]string
+	ErrorMsg    string
+	Attempts    int
+	CallbackURL string
+}
+
+// Delivery records what happened when a finished report was emailed.
+type Delivery struct {
+	Status     string    `bson:"status"` // sent | failed | skipped
+	Mode       string    `bson:"mode,omitempty"`
+	Recipients []string  `bson:"recipients,omitempty"`
+	Error      string    `bson:"error,omitempty"`
+	SentAt     time.Time `bson:"sentAt,omitempty"`
 }
 
 type ReportDoc struct {
-	ID        primitive.ObjectID     `bson:"_id"`
-	Type      string                 `bson:"type"`
-	Status    string                 `bson:"status"`
-	FileURL   string                 `bson:"fileURL"`
-	ErrorMsg  string                 `bson:"errorMsg"`
-	Filters   map[string]interface{} `bson:"filters"`
-	CreatedAt primitive.DateTime     `bson:"createdAt"`
-	UpdatedAt primitive.DateTime     `bson:"updatedAt"`
+	ID          primitive.ObjectID     `bson:"_id"`
+	Type        string                 `bson:"type"`
+	Status      string                 `bson:"status"`
+	FileURL     string                 `bson:"fileURL"`
+	ErrorMsg    string                 `bson:"errorMsg"`
+	Filters     map[string]interface{} `bson:"filters"`
+	Attempts    int                    `bson:"attempts,omitempty"`
+	NextRetryAt primitive.DateTime     `bson:"nextRetryAt,omitempty"`
+	WorkerID    string                 `bson:"workerID,omitempty"`
+	LeaseUntil  primitive.DateTime     `bson:"leaseExpiresAt,omitempty"`
+	Progress    *Progress              `bson:"progress,omitempty"`
+	CallbackURL string                 `bson:"callbackURL,omitempty"`
+	// Formats lists every output format to render ("pdf", "xlsx", "html");
+	// OutputFormat is the older single-format field used when it is empty.
+	// FileURLs maps each format to its file, FileURL is the first one.
+	Formats      []string          `bson:"formats,omitempty"`
+	OutputFormat string            `bson:"output_format,omitempty"`
+	FileURLs     map[string]string `bson:"fileURLs,omitempty"`
+	// Exports lists raw-data exports ("csv", "json") saved next to the file;
+	// their URLs end up in ExportURLs.
+	Exports    []string          `bson:"exports,omitempty"`
+	ExportURLs map[string]string `bson:"exportURLs,omitempty"`
+	// Template names the report template for PDF and HTML output; empty
+	// means the template named after the report type.
+	Template string `bson:"template,omitempty"`
+	// Recipients get the finished report by email; EmailMode is "attach"
+	// (default) or "link".
+	Recipients []string           `bson:"recipients,omitempty"`
+	EmailMode  string             `bson:"emailMode,omitempty"`
+	Delivery   *Delivery          `bson:"delivery,omitempty"`
+	CreatedAt  primitive.DateTime `bson:"createdAt"`
+	UpdatedAt  primitive.DateTime `bson:"updatedAt"`
 }
 
 type ImageJobDoc struct {
@@ -33,6 +94,12 @@ type ImageJobDoc struct {
 	SourceImageURL string             `bson:"sourceImageURL"`
 	OutputImageURL string             `bson:"outputImageURL"`
 	ErrorMsg       string             `bson:"errorMsg"`
+	Attempts       int                `bson:"attempts,omitempty"`
+	NextRetryAt    time.Time          `bson:"nextRetryAt,omitempty"`
+	WorkerID       string             `bson:"workerID,omitempty"`
+	LeaseUntil     time.Time          `bson:"leaseExpiresAt,omitempty"`
+	Progress       *Progress          `bson:"progress,omitempty"`
+	CallbackURL    string             `bson:"callbackURL,omitempty"`
 	CreatedAt      time.Time          `bson:"createdAt"`
 	UpdatedAt      time.Time          `bson:"updatedAt"`
 }
@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckFunc reports whether a dependency is reachable.
+type CheckFunc func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker backs the /healthz and /readyz endpoints. Liveness only looks at
+// the main loop; readiness also checks every registered dependency.
+type Checker struct {
+	maxLoopAge time.Duration
+	lastLoop   atomic.Int64
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+func NewChecker(maxLoopAge time.Duration) *Checker {
+	c := &Checker{maxLoopAge: maxLoopAge}
+	c.LoopAlive()
+	return c
+}
+
+// AddCheck registers a dependency for /readyz.
+func (c *Checker) AddCheck(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// LoopAlive records that the main loop completed a queue read.
+func (c *Checker) LoopAlive() {
+	c.lastLoop.Store(time.Now().UnixNano())
+}
+
+func (c *Checker) loopAge() time.Duration {
+	return time.Since(time.Unix(0, c.lastLoop.Load()))
+}
+
+type response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Register mounts /healthz and /readyz on mux.
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", c.handleLive)
+	mux.HandleFunc("/readyz", c.handleReady)
+}
+
+func (c *Checker) handleLive(w http.ResponseWriter, r *http.Request) {
+	res := response{Status: "ok", Checks: map[string]string{}}
+	res.Checks["main_loop"] = c.loopCheck()
+	if res.Checks["main_loop"] != "ok" {
+		res.Status = "fail"
+	}
+	writeResponse(w, res)
+}
+
+func (c *Checker) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	res := response{Status: "ok", Checks: map[string]string{}}
+	res.Checks["main_loop"] = c.loopCheck()
+
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			result := "ok"
+			if err := nc.check(ctx); err != nil {
+				result = err.Error()
+			}
+			mu.Lock()
+			res.Checks[nc.name] = result
+			mu.Unlock()
+		}(nc)
+	}
+	wg.Wait()
+
+	for _, result := range res.Checks {
+		if result != "ok" {
+			res.Status = "fail"
+		}
+	}
+	writeResponse(w, res)
+}
+
+func (c *Checker) loopCheck() string {
+	if age := c.loopAge(); age > c.maxLoopAge {
+		return "no queue read for " + age.Round(time.Second).String()
+	}
+	return "ok"
+}
+
+func writeResponse(w http.ResponseWriter, res response) {
+	w.Header().Set("Content-Type", "application/json")
+	if res.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,226 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parse splits a built message into its text body and attachments and fails
+// the test on any MIME structure the mailer should not produce.
+func parse(t *testing.T, raw []byte) (*mail.Message, string, []Attachment) {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" || params["boundary"] == "" {
+		t.Fatalf("Content-Type = %q", msg.Header.Get("Content-Type"))
+	}
+
+	var text string
+	var attachments []Attachment
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if part.FileName() == "" {
+			if part.Header.Get("Content-Transfer-Encoding") != "quoted-printable" {
+				t.Errorf("text part encoding = %q", part.Header.Get("Content-Transfer-Encoding"))
+			}
+			text = string(raw)
+			continue
+		}
+		if part.Header.Get("Content-Transfer-Encoding") != "base64" {
+			t.Errorf("attachment %s encoding = %q", part.FileName(), part.Header.Get("Content-Transfer-Encoding"))
+		}
+		// Messages read back from the sink have bare "\n" line endings.
+		lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimSuffix(line, "\r")
+			if len(lines[i]) > 76 {
+				t.Errorf("attachment %s has a %d-character line", part.FileName(), len(lines[i]))
+			}
+		}
+		data, err := base64.StdEncoding.DecodeString(strings.Join(lines, ""))
+		if err != nil {
+			t.Fatalf("attachment %s: %v", part.FileName(), err)
+		}
+		attachments = append(attachments, Attachment{Filename: part.FileName(), ContentType: part.Header.Get("Content-Type"), Data: data})
+	}
+	return msg, text, attachments
+}
+
+func TestBuild(t *testing.T) {
+	pdf := bytes.Repeat([]byte("%PDF-1.7 laporan "), 40)
+	m := NewMailer("localhost", 25, "", "", "laporan@example.org")
+	raw, err := m.build(Message{
+		To:      []string{"a@example.org", "b@example.org"},
+		Subject: "Laporan Keuangan – Mei",
+		Body:    "Laporan terlampir.",
+		Attachments: []Attachment{
+			{Filename: "financial-1.pdf", ContentType: "application/pdf", Data: pdf},
+			{Filename: "laporan mei.csv", ContentType: "text/csv", Data: []byte("a,b\n1,2\n")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, text, attachments := parse(t, raw)
+	if got := msg.Header.Get("To"); got != "a@example.org, b@example.org" {
+		t.Errorf("To = %q", got)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil || subject != "Laporan Keuangan – Mei" {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	if text != "Laporan terlampir." {
+		t.Errorf("body = %q", text)
+	}
+	if len(attachments) != 2 {
+		t.Fatalf("got %d attachments, want 2", len(attachments))
+	}
+	if a := attachments[0]; a.Filename != "financial-1.pdf" || a.ContentType != "application/pdf" || !bytes.Equal(a.Data, pdf) {
+		t.Errorf("first attachment = %s %s, %d bytes", a.Filename, a.ContentType, len(a.Data))
+	}
+	if a := attachments[1]; a.Filename != "laporan mei.csv" || string(a.Data) != "a,b\n1,2\n" {
+		t.Errorf("second attachment = %s, %q", a.Filename, a.Data)
+	}
+}
+
+// smtpSink accepts one SMTP session without TLS or auth and hands back
+// what it received.
+type smtpSink struct {
+	addr *net.TCPAddr
+	done chan sinkResult
+}
+
+type sinkResult struct {
+	from string
+	to   []string
+	data []byte
+	err  error
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &smtpSink{addr: ln.Addr().(*net.TCPAddr), done: make(chan sinkResult, 1)}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.done <- sinkResult{err: err}
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		s.done <- serveSMTP(textproto.NewConn(conn))
+	}()
+	return s
+}
+
+func serveSMTP(c *textproto.Conn) sinkResult {
+	var res sinkResult
+	reply := func(line string) error { return c.PrintfLine("%s", line) }
+	if res.err = reply("220 sink ready"); res.err != nil {
+		return res
+	}
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			res.err = err
+			return res
+		}
+		verb := strings.ToUpper(strings.Fields(line)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			err = reply("250 sink")
+		case "MAIL":
+			res.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+			err = reply("250 ok")
+		case "RCPT":
+			res.to = append(res.to, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			err = reply("250 ok")
+		case "DATA":
+			if err = reply("354 go ahead"); err == nil {
+				res.data, err = c.ReadDotBytes()
+			}
+			if err == nil {
+				err = reply("250 queued")
+			}
+		case "QUIT":
+			res.err = reply("221 bye")
+			return res
+		default:
+			err = reply("502 not implemented")
+		}
+		if err != nil {
+			res.err = err
+			return res
+		}
+	}
+}
+
+func TestSendToLocalSink(t *testing.T) {
+	sink := newSMTPSink(t)
+	m := NewMailer("127.0.0.1", sink.addr.Port, "", "", "laporan@example.org")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := m.Send(ctx, Message{
+		To:          []string{"a@example.org", "b@example.org"},
+		Subject:     "Laporan",
+		Body:        "Laporan terlampir.",
+		Attachments: []Attachment{{Filename: "report.xlsx", ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Data: []byte("PK\x03\x04")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := <-sink.done
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if res.from != "laporan@example.org" {
+		t.Errorf("MAIL FROM = %q", res.from)
+	}
+	if strings.Join(res.to, ",") != "a@example.org,b@example.org" {
+		t.Errorf("RCPT TO = %q", res.to)
+	}
+	_, text, attachments := parse(t, res.data)
+	if text != "Laporan terlampir." {
+		t.Errorf("body = %q", text)
+	}
+	if len(attachments) != 1 || attachments[0].Filename != "report.xlsx" || string(attachments[0].Data) != "PK\x03\x04" {
+		t.Errorf("attachments = %+v", attachments)
+	}
+}
+
+func TestSendWithoutRecipients(t *testing.T) {
+	m := NewMailer("127.0.0.1", 1, "", "", "laporan@example.org")
+	if err := m.Send(context.Background(), Message{Subject: "x"}); err == nil {
+		t.Error("Send accepted a message without recipients")
+	}
+}
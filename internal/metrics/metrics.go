@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"org-worker/internal/storage"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	JobsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "worker_jobs_total",
+		Help: "Jobs handled, by task type and outcome.",
+	}, []string{"task_type", "outcome"})
+
+	JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "worker_job_duration_seconds",
+		Help:    "Time spent running a job, by task type.",
+		Buckets: []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"task_type"})
+
+	ReportsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "worker_reports_total",
+		Help: "Reports generated, by report type and outcome.",
+	}, []string{"report_type", "outcome"})
+
+	ReportDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "worker_report_duration_seconds",
+		Help:    "Time spent generating a report, by report type.",
+		Buckets: []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"report_type"})
+
+	ImageDownloadFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "worker_image_download_failures_total",
+		Help: "Image downloads that failed, by source (report_photo or image_job).",
+	}, []string{"source"})
+
+	StorageUploadDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "worker_storage_upload_seconds",
+		Help:    "Storage upload latency, by provider and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"provider", "outcome"})
+)
+
+// RegisterConcurrency exposes how many of the limit's slots are in use.
+func RegisterConcurrency(inUse func() int, limit int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "worker_concurrency_in_use",
+		Help: "Job slots currently occupied.",
+	}, func() float64 { return float64(inUse()) })
+	promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "worker_concurrency_limit",
+		Help: "Maximum number of jobs run at the same time.",
+	}).Set(float64(limit))
+}
+
+// RegisterPool exposes the occupied and total slots of one concurrency pool.
+func RegisterPool(pool string, inUse func() int, limit int) {
+	labels := prometheus.Labels{"pool": pool}
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "worker_pool_in_use",
+		Help:        "Job slots currently occupied in a concurrency pool.",
+		ConstLabels: labels,
+	}, func() float64 { return float64(inUse()) })
+	promauto.NewGauge(prometheus.GaugeOpts{
+		Name:        "worker_pool_limit",
+		Help:        "Maximum number of jobs of a concurrency pool run at the same time.",
+		ConstLabels: labels,
+	}).Set(float64(limit))
+}
+
+// queueDepthCollector reads list/sorted-set lengths from Redis on every
+// scrape instead of polling in the background.
+type queueDepthCollector struct {
+	client *redis.Client
+	lists  []string
+	zsets  []string
+	desc   *prometheus.Desc
+}
+
+// RegisterQueueDepth exposes the length of the given Redis lists and sorted
+// sets as worker_queue_depth{queue="..."}.
+func RegisterQueueDepth(client *redis.Client, lists, zsets []string) {
+	prometheus.MustRegister(&queueDepthCollector{
+		client: client,
+		lists:  lists,
+		zsets:  zsets,
+		desc:   prometheus.NewDesc("worker_queue_depth", "Jobs waiting in a Redis queue.", []string{"queue"}, nil),
+	})
+}
+
+func (c *queueDepthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *queueDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, name := range c.lists {
+		if n, err := c.client.LLen(ctx, name).Result(); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), name)
+		}
+	}
+	for _, name := range c.zsets {
+		if n, err := c.client.ZCard(ctx, name).Result(); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), name)
+		}
+	}
+}
+
+// instrumentedStorage times every Save of the wrapped provider.
+type instrumentedStorage struct {
+	next     storage.StorageProvider
+	provider string
+}
+
+// InstrumentStorage wraps a StorageProvider so upload latency is recorded
+// under the given provider name.
+func InstrumentStorage(next storage.StorageProvider, provider string) storage.StorageProvider {
+	return &instrumentedStorage{next: next, provider: provider}
+}
+
+func (s *instrumentedStorage) Save(ctx context.Context, reportType, filename string, file *bytes.Buffer) (string, error) {
+	start := time.Now()
+	url, err := s.next.Save(ctx, reportType, filename, file)
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	StorageUploadDuration.WithLabelValues(s.provider, outcome).Observe(time.Since(start).Seconds())
+	return url, err
+}
+
+func (s *instrumentedStorage) Ping(ctx context.Context) error {
+	if checker, ok := s.next.(storage.HealthChecker); ok {
+		return checker.Ping(ctx)
+	}
+	return nil
+}
+
+// NewMux returns the mux the worker's HTTP listener serves, with /metrics
+// already mounted.
+func NewMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return mux
+}
+
+// Serve runs the HTTP listener on addr until ctx is cancelled.
+func Serve(ctx context.Context, addr string, handler http.Handler, logger *slog.Logger) {
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	logger.Info("HTTP listener started", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("HTTP listener stopped", "err", err)
+	}
+}
@@ -0,0 +1,181 @@
+// Package notify announces finished jobs on a Redis channel and, when the
+// tracking document has a callbackURL, with a signed webhook.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"org-worker/internal/retry"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Event is the body of both the pub/sub message and the webhook request.
+type Event struct {
+	Event      string            `json:"event"`
+	TaskType   string            `json:"taskType"`
+	DocumentID string            `json:"documentID"`
+	Status     string            `json:"status"`
+	ResultURL  string            `json:"resultURL,omitempty"`
+	ResultURLs map[string]string `json:"resultURLs,omitempty"`
+	ErrorMsg   string            `json:"errorMsg,omitempty"`
+	Attempts   int               `json:"attempts,omitempty"`
+	Timestamp  time.Time         `json:"timestamp"`
+}
+
+// EventName maps a final job outcome to the event type sent out.
+func EventName(outcome string) string {
+	switch outcome {
+	case "completed":
+		return "job.completed"
+	case "cancelled":
+		return "job.cancelled"
+	}
+	return "job.failed"
+}
+
+type Notifier struct {
+	client  *redis.Client
+	channel string
+	secret  []byte
+	policy  retry.Policy
+	http    *http.Client
+
+	// stop cancels webhook deliveries still running when Close gives up.
+	stop       context.Context
+	cancelStop context.CancelFunc
+	deliveries sync.WaitGroup
+}
+
+// NewNotifier publishes on channel. With an empty secret webhooks are sent
+// unsigned.
+func NewNotifier(client *redis.Client, channel, secret string, policy retry.Policy, timeout time.Duration) *Notifier {
+	stop, cancelStop := context.WithCancel(context.Background())
+	return &Notifier{
+		client:     client,
+		channel:    channel,
+		secret:     []byte(secret),
+		policy:     policy,
+		http:       &http.Client{Timeout: timeout},
+		stop:       stop,
+		cancelStop: cancelStop,
+	}
+}
+
+// Notify publishes the event and, if callbackURL is set, delivers the
+// webhook in the background so the job slot is not held up by it. The
+// delivery outlives ctx; Close waits for it.
+func (n *Notifier) Notify(ctx context.Context, logger *slog.Logger, event Event, callbackURL string) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("Failed to encode completion event", "err", err)
+		return
+	}
+	if err := n.client.Publish(ctx, n.channel, body).Err(); err != nil {
+		logger.Error("Failed to publish completion event", "err", err)
+	}
+	if callbackURL != "" {
+		n.deliveries.Add(1)
+		go func() {
+			defer n.deliveries.Done()
+			n.deliver(n.stop, logger, callbackURL, body)
+		}()
+	}
+}
+
+// Close waits for webhook deliveries still in progress. If ctx expires
+// first, the remaining deliveries are abandoned and logged as undelivered.
+func (n *Notifier) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		n.deliveries.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		n.cancelStop()
+		return nil
+	case <-ctx.Done():
+		n.cancelStop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// deliver POSTs the webhook until it succeeds, gets a non-retryable
+// response, the retry policy runs out, or ctx is cancelled by Close.
+func (n *Notifier) deliver(ctx context.Context, logger *slog.Logger, url string, body []byte) {
+	logger = logger.With("callbackURL", url)
+	for attempt := 1; ; attempt++ {
+		err := n.post(ctx, url, body)
+		if err == nil {
+			logger.Info("Webhook delivered", "attempt", attempt)
+			return
+		}
+		if ctx.Err() != nil {
+			logger.Error("Webhook not delivered before shutdown", "attempt", attempt, "err", err)
+			return
+		}
+		if !n.policy.CanRetry(attempt, err) {
+			logger.Error("Webhook delivery failed", "attempt", attempt, "err", err)
+			return
+		}
+		delay := n.policy.Backoff(attempt)
+		logger.Warn("Webhook delivery failed, retrying", "attempt", attempt, "delay", delay, "err", err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			logger.Error("Webhook not delivered before shutdown", "attempt", attempt, "err", err)
+			return
+		}
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	if len(n.secret) > 0 {
+		req.Header.Set("X-Webhook-Signature", "sha256="+Sign(n.secret, timestamp, body))
+	}
+
+	resp, err := n.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	default:
+		return retry.Permanent(fmt.Errorf("webhook returned %s", resp.Status))
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>". Receivers
+// recompute it to check the request came from the worker and was not
+// replayed with a different timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
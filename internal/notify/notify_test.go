@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"org-worker/internal/retry"
+)
+
+func TestSign(t *testing.T) {
+	// Computed independently: HMAC-SHA256("secret", `1700000000.{"event":"job.completed"}`).
+	const want = "e33f34cc0b46f4e752fe75a10d7177366fd795c052ed09dfa63608265c13be69"
+	if got := Sign([]byte("secret"), "1700000000", []byte(`{"event":"job.completed"}`)); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if Sign([]byte("secret"), "1700000001", []byte(`{"event":"job.completed"}`)) == want {
+		t.Error("signature does not cover the timestamp")
+	}
+}
+
+func TestPost(t *testing.T) {
+	body := []byte(`{"event":"job.completed"}`)
+	tests := []struct {
+		name          string
+		status        int
+		secret        string
+		wantErr       bool
+		wantRetryable bool
+	}{
+		{"ok", http.StatusOK, "secret", false, false},
+		{"no content", http.StatusNoContent, "", false, false},
+		{"server error", http.StatusInternalServerError, "secret", true, true},
+		{"unavailable", http.StatusServiceUnavailable, "secret", true, true},
+		{"too many requests", http.StatusTooManyRequests, "secret", true, true},
+		{"bad request", http.StatusBadRequest, "secret", true, false},
+		{"not found", http.StatusNotFound, "secret", true, false},
+		{"gone", http.StatusGone, "secret", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ := io.ReadAll(r.Body)
+				if string(got) != string(body) {
+					t.Errorf("body = %s, want %s", got, body)
+				}
+				if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type = %q", ct)
+				}
+				ts := r.Header.Get("X-Webhook-Timestamp")
+				if _, err := strconv.ParseInt(ts, 10, 64); err != nil {
+					t.Errorf("X-Webhook-Timestamp = %q", ts)
+				}
+				sig := r.Header.Get("X-Webhook-Signature")
+				if tt.secret == "" {
+					if sig != "" {
+						t.Errorf("unsigned webhook carries X-Webhook-Signature %q", sig)
+					}
+				} else if want := "sha256=" + Sign([]byte(tt.secret), ts, body); sig != want {
+					t.Errorf("X-Webhook-Signature = %q, want %q", sig, want)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			n := NewNotifier(nil, "events", tt.secret, retry.Policy{}, time.Second)
+			err := n.post(context.Background(), srv.URL, body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("post: err %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && retry.IsRetryable(err) != tt.wantRetryable {
+				t.Errorf("post: retryable %v, want %v (err %v)", retry.IsRetryable(err), tt.wantRetryable, err)
+			}
+		})
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []int
+		wantCalls int32
+	}{
+		{"delivered first time", []int{200}, 1},
+		{"retried after 503 and 429", []int{503, 429, 200}, 3},
+		{"gives up after MaxAttempts", []int{500, 500, 500, 500}, 3},
+		{"permanent 4xx is not retried", []int{400, 200}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := calls.Add(1) - 1
+				w.WriteHeader(tt.responses[min(int(i), len(tt.responses)-1)])
+			}))
+			defer srv.Close()
+
+			policy := retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+			n := NewNotifier(nil, "events", "secret", policy, time.Second)
+			n.deliver(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), srv.URL, []byte(`{}`))
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("webhook called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCloseAbandonsPendingDeliveries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	policy := retry.Policy{MaxAttempts: 100, BaseDelay: time.Hour}
+	n := NewNotifier(nil, "events", "", policy, time.Second)
+	n.deliveries.Add(1)
+	go func() {
+		defer n.deliveries.Done()
+		n.deliver(n.stop, slog.New(slog.NewTextHandler(io.Discard, nil)), srv.URL, []byte(`{}`))
+	}()
+
+	// Wait for the first attempt so Close catches the delivery backing off.
+	for deadline := time.Now().Add(5 * time.Second); calls.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := n.Close(ctx); err == nil {
+		t.Error("Close reported success while a delivery was still backing off")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Close took %s; the backoff sleep ignored cancellation", elapsed)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("webhook called %d times, want 1", calls.Load())
+	}
+}
@@ -2,14 +2,21 @@ package image
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"org-worker/internal/domain"
+	"org-worker/internal/metrics"
+	"org-worker/internal/progress"
 	"org-worker/internal/repository"
+	"org-worker/internal/retry"
 	"org-worker/internal/storage"
+	"org-worker/internal/task"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,10 +25,81 @@ import (
 type ImageHandler struct {
 	repo    *repository.ImageJobRepository
 	storage storage.StorageProvider
+	policy  retry.Policy
 }
 
-func NewImageHandler(repo *repository.ImageJobRepository, storage storage.StorageProvider) *ImageHandler {
-	return &ImageHandler{repo: repo, storage: storage}
+func NewImageHandler(repo *repository.ImageJobRepository, storage storage.StorageProvider, policy retry.Policy) *ImageHandler {
+	return &ImageHandler{repo: repo, storage: storage, policy: policy}
+}
+
+// TaskType is the task_type producers use to request image processing.
+const TaskType = "process_image"
+
+// downloadClient caps a single source download; the job context's deadline
+// still applies on top of it.
+var downloadClient = &http.Client{Timeout: time.Minute}
+
+// Register adds the process_image task to the worker's registry.
+func (h *ImageHandler) Register(registry *task.Registry) {
+	registry.Register(TaskType, task.JSONDecoder[domain.ImageJobPayload](), h)
+}
+
+func (h *ImageHandler) Handle(ctx context.Context, logger *slog.Logger, payload any) error {
+	p := payload.(domain.ImageJobPayload)
+	logger.Info("Starting job processing", "imageJobID", p.ImageJobID)
+	return h.HandleImageProcessing(ctx, logger, p.ImageJobID)
+}
+
+func (h *ImageHandler) DocumentID(payload any) string {
+	return payload.(domain.ImageJobPayload).ImageJobID
+}
+
+func (h *ImageHandler) Status(ctx context.Context, payload any) (string, error) {
+	id, err := primitive.ObjectIDFromHex(payload.(domain.ImageJobPayload).ImageJobID)
+	if err != nil {
+		return "", retry.Permanent(err)
+	}
+	return h.repo.GetStatus(ctx, id)
+}
+
+func (h *ImageHandler) Claim(ctx context.Context, payload any, owner string, leaseUntil time.Time) error {
+	p := payload.(domain.ImageJobPayload)
+	id, err := primitive.ObjectIDFromHex(p.ImageJobID)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	return h.repo.Claim(ctx, id, owner, leaseUntil, p.Force)
+}
+
+func (h *ImageHandler) Release(ctx context.Context, payload any, owner string) error {
+	id, err := primitive.ObjectIDFromHex(payload.(domain.ImageJobPayload).ImageJobID)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	return h.repo.Release(ctx, id, owner)
+}
+
+func (h *ImageHandler) MarkStatus(ctx context.Context, payload any, status, errMsg string) error {
+	p := payload.(domain.ImageJobPayload)
+	id, err := primitive.ObjectIDFromHex(p.ImageJobID)
+	if err != nil {
+		return err
+	}
+	return h.repo.UpdateStatus(ctx, id, task.Owner(ctx), bson.M{"status": status, "errorMsg": errMsg})
+}
+
+func (h *ImageHandler) Result(ctx context.Context, payload any) (domain.JobResult, error) {
+	doc, err := h.repo.FindByID(ctx, payload.(domain.ImageJobPayload).ImageJobID)
+	if err != nil {
+		return domain.JobResult{}, err
+	}
+	return domain.JobResult{
+		Status:      doc.Status,
+		ResultURL:   doc.OutputImageURL,
+		ErrorMsg:    doc.ErrorMsg,
+		Attempts:    doc.Attempts,
+		CallbackURL: doc.CallbackURL,
+	}, nil
 }
 
 func (h *ImageHandler) HandleImageProcessing(ctx context.Context, logger *slog.Logger, jobID string) error {
@@ -29,30 +107,48 @@ func (h *ImageHandler) HandleImageProcessing(ctx context.Context, logger *slog.L
 	// 1. Ambil data Job terbaru dari DB
 	imageJob, err := h.repo.FindByID(ctx, jobID)
 	if err != nil {
-		return fmt.Errorf("failed to find job: %v", err)
+		return fmt.Errorf("failed to find job: %w", err)
 	}
 
-	logger.Info("Processing image", "jobID", jobID, "source", imageJob.SourceImageURL)
+	attempt := imageJob.Attempts + 1
+	if n, err := h.repo.IncrementAttempts(ctx, imageJob.ID); err == nil {
+		attempt = n
+	}
+
+	logger.Info("Processing image", "jobID", jobID, "source", imageJob.SourceImageURL, "attempt", attempt)
+
+	reporter := progress.NewReporter(func(ctx context.Context, p domain.Progress) error {
+		return h.repo.UpdateProgress(ctx, imageJob.ID, p)
+	})
+	reporter.Update(ctx, progress.StageDownloadingImages, 10, "downloading image")
 
 	// 2. Download gambar mentah
-	resp, err := http.Get(imageJob.SourceImageURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageJob.SourceImageURL, nil)
 	if err != nil {
-		h.handleError(ctx, imageJob.ID, "failed to download request: "+err.Error())
-		return err
+		return h.handleError(ctx, imageJob.ID, attempt, retry.Permanent(fmt.Errorf("invalid source url: %w", err)))
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		metrics.ImageDownloadFailures.WithLabelValues("image_job").Inc()
+		return h.handleError(ctx, imageJob.ID, attempt, fmt.Errorf("failed to download request: %w", err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		errMsg := fmt.Sprintf("failed to download image, status: %d", resp.StatusCode)
-		h.handleError(ctx, imageJob.ID, errMsg)
-		return fmt.Errorf("%s", errMsg)
+		metrics.ImageDownloadFailures.WithLabelValues("image_job").Inc()
+		err := fmt.Errorf("failed to download image, status: %d", resp.StatusCode)
+		// 4xx berarti URL sumber salah, percobaan ulang tidak akan membantu
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			err = retry.Permanent(err)
+		}
+		return h.handleError(ctx, imageJob.ID, attempt, err)
 	}
 
 	// 3. Proses Gambar (Stream -> Memory -> Stream)
+	reporter.Update(ctx, progress.StageProcessing, 40, "processing")
 	webpBuf, err := ProcessImage(resp.Body)
 	if err != nil {
-		h.handleError(ctx, imageJob.ID, "failed to process image: "+err.Error())
-		return err
+		return h.handleError(ctx, imageJob.ID, attempt, retry.Permanent(fmt.Errorf("failed to process image: %w", err)))
 	}
 
 	// 4. Tentukan nama file
@@ -61,10 +157,10 @@ func (h *ImageHandler) HandleImageProcessing(ctx context.Context, logger *slog.L
 	newFilename := baseName + "-optimized.webp"
 
 	// 5. Upload ke R2 (Gunakan h.storage, bukan parameter luar)
+	reporter.Update(ctx, progress.StageUploading, 80, "uploading")
 	imageURL, err := h.storage.Save(ctx, "optimized", newFilename, webpBuf)
 	if err != nil {
-		h.handleError(ctx, imageJob.ID, "failed to upload image: "+err.Error())
-		return err
+		return h.handleError(ctx, imageJob.ID, attempt, fmt.Errorf("failed to upload image: %w", err))
 	}
 
 	// 6. Update Sukses
@@ -74,18 +170,39 @@ func (h *ImageHandler) HandleImageProcessing(ctx context.Context, logger *slog.L
 		"errorMsg":       "",
 	}
 
-	if err := h.repo.UpdateStatus(ctx, imageJob.ID, updateData); err != nil {
+	if err := h.repo.UpdateStatus(ctx, imageJob.ID, task.Owner(ctx), updateData); err != nil {
+		if errors.Is(err, domain.ErrLeaseLost) {
+			return err
+		}
 		logger.Error("Failed to update success status", "err", err)
-		return err
+		return h.handleError(ctx, imageJob.ID, attempt, err)
 	}
 
+	reporter.Update(ctx, progress.StageCompleted, 100, "completed")
 	logger.Info("Image processing completed", "jobID", jobID, "url", imageURL)
 	return nil
 }
 
-func (h *ImageHandler) handleError(ctx context.Context, id primitive.ObjectID, msg string) {
-	h.repo.UpdateStatus(ctx, id, bson.M{
+// handleError records the failure on the job and, when the policy allows
+// another attempt, returns a retry.ScheduledError so the caller re-enqueues it.
+// A job whose claim was lost is left to its new owner.
+func (h *ImageHandler) handleError(ctx context.Context, id primitive.ObjectID, attempt int, err error) error {
+	if h.policy.CanRetry(attempt, err) {
+		delay := h.policy.Backoff(attempt)
+		if werr := h.repo.UpdateStatus(ctx, id, task.Owner(ctx), bson.M{
+			"status":      "retrying",
+			"errorMsg":    err.Error(),
+			"nextRetryAt": time.Now().Add(delay),
+		}); errors.Is(werr, domain.ErrLeaseLost) {
+			return werr
+		}
+		return &retry.ScheduledError{Err: err, Attempt: attempt, Delay: delay}
+	}
+	if werr := h.repo.UpdateStatus(ctx, id, task.Owner(ctx), bson.M{
 		"status":   "failed",
-		"errorMsg": msg,
-	})
+		"errorMsg": err.Error(),
+	}); errors.Is(werr, domain.ErrLeaseLost) {
+		return werr
+	}
+	return &retry.ExhaustedError{Err: err, Attempt: attempt}
 }
@@ -3,24 +3,39 @@ package report
 import (
 	"bytes"
 	"fmt"
-	"org-worker/internal/domain"
+	"math"
 
 	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
 )
 
-func createPieChartImage(stats []domain.DemographicStat, total int64) ([]byte, error) {
+// maxLineChartLabels is how many x-axis labels a line chart shows at most;
+// longer series label every second, third, ... point.
+const maxLineChartLabels = 12
+
+// chartPoint is one slice of a pie chart, one bar of a bar chart or one
+// point of a line chart.
+type chartPoint struct {
+	Label string
+	Value float64
+}
+
+func createPieChartImage(l *localizer, th *Theme, points []chartPoint, total float64) ([]byte, error) {
 	if total == 0 {
 		return nil, nil
 	}
 	var values []chart.Value
-	for _, stat := range stats {
-		percentage := (float64(stat.Count) / float64(total)) * 100
-		label := fmt.Sprintf("%s (%.1f%%)", stat.ID, percentage)
-		values = append(values, chart.Value{Value: float64(stat.Count), Label: label})
+	var labels []string
+	for _, p := range points {
+		percentage := (p.Value / total) * 100
+		label := l.Sprintf("%s (%.1f%%)", p.Label, percentage)
+		values = append(values, chart.Value{Value: p.Value, Label: label})
+		labels = append(labels, label)
 	}
 	pie := chart.PieChart{
 		Width:  512,
 		Height: 512,
+		Font:   th.chartFont(labels...),
 		Values: values,
 	}
 	buf := new(bytes.Buffer)
@@ -30,14 +45,17 @@ func createPieChartImage(stats []domain.DemographicStat, total int64) ([]byte, e
 	return buf.Bytes(), nil
 }
 
-func createBarChartImage(stats []domain.MilestoneStat, title string) ([]byte, error) {
+func createBarChartImage(th *Theme, points []chartPoint, title string) ([]byte, error) {
 	var values []chart.Value
-	for _, stat := range stats {
-		values = append(values, chart.Value{Value: float64(stat.Count), Label: stat.ID})
+	labels := []string{title}
+	for _, p := range points {
+		values = append(values, chart.Value{Value: p.Value, Label: p.Label})
+		labels = append(labels, p.Label)
 	}
 	bar := chart.BarChart{
 		Width:  512,
 		Height: 512,
+		Font:   th.chartFont(labels...),
 		Bars:   values,
 	}
 	if title != "" {
@@ -50,10 +68,64 @@ func createBarChartImage(stats []domain.MilestoneStat, title string) ([]byte, er
 	return buf.Bytes(), nil
 }
 
-func convertFinancialStatToDemographic(stats []domain.FinancialStat) []domain.DemographicStat {
-	var result []domain.DemographicStat
-	for _, s := range stats {
-		result = append(result, domain.DemographicStat{ID: s.ID, Count: int(s.Total)})
+// createLineChartImage draws the points in order, evenly spaced, with their
+// labels on the x-axis. It is wide and short to span a page.
+func createLineChartImage(th *Theme, points []chartPoint, title string) ([]byte, error) {
+	if len(points) == 0 {
+		return nil, nil
+	}
+	step := (len(points) + maxLineChartLabels - 1) / maxLineChartLabels
+	labels := []string{title}
+	var xs, ys []float64
+	// go-chart spans the axes from the first to the last tick; the unlabeled
+	// ends leave room around the outer points and let a single point draw.
+	xTicks := []chart.Tick{{Value: -0.5}}
+	top := 0.0
+	for i, p := range points {
+		xs = append(xs, float64(i))
+		ys = append(ys, p.Value)
+		top = math.Max(top, p.Value)
+		if i%step == 0 {
+			xTicks = append(xTicks, chart.Tick{Value: float64(i), Label: p.Label})
+			labels = append(labels, p.Label)
+		}
+	}
+	xTicks = append(xTicks, chart.Tick{Value: float64(len(points)) - 0.5})
+
+	// Counts get whole-number ticks from zero.
+	yStep := math.Max(1, math.Ceil(top/5))
+	yMax := math.Max(yStep, math.Ceil(top/yStep)*yStep)
+	var yTicks []chart.Tick
+	for v := 0.0; v <= yMax; v += yStep {
+		yTicks = append(yTicks, chart.Tick{Value: v, Label: fmt.Sprintf("%.0f", v)})
 	}
-	return result
+
+	color := drawing.Color{R: uint8(th.Primary.Red), G: uint8(th.Primary.Green), B: uint8(th.Primary.Blue), A: 255}
+	graph := chart.Chart{
+		Title:  title,
+		Width:  1024,
+		Height: 360,
+		Font:   th.chartFont(labels...),
+		// Keeps the title clear of the plot.
+		Background: chart.Style{Padding: chart.Box{Top: 50, Left: 20, Right: 20, Bottom: 10}},
+		XAxis:      chart.XAxis{Ticks: xTicks},
+		YAxis:      chart.YAxis{Ticks: yTicks},
+		Series: []chart.Series{
+			chart.ContinuousSeries{
+				Style: chart.Style{
+					StrokeColor: color,
+					StrokeWidth: 3,
+					DotColor:    color,
+					DotWidth:    4,
+				},
+				XValues: xs,
+				YValues: ys,
+			},
+		},
+	}
+	buf := new(bytes.Buffer)
+	if err := graph.Render(chart.PNG, buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,81 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"org-worker/internal/domain"
+	"org-worker/internal/mail"
+)
+
+// maxAttachmentSize caps the combined size of the attached report files;
+// bigger reports are sent as links even when EmailMode asks for attachments.
+const maxAttachmentSize = 10 << 20
+
+var reportTitles = map[string]string{
+	"community_activity":       "Laporan Aktivitas Komunitas",
+	"participant_demographics": "Laporan Demografi Peserta",
+	"program_impact":           "Laporan Dampak Program",
+	"financial_summary":        "Laporan Transparansi Keuangan",
+}
+
+// deliver emails the finished report to its recipients and records the
+// outcome on the report. A failed delivery does not fail the report, and a
+// report that was already sent is not sent again on a retry.
+func (h *ReportHandler) deliver(ctx context.Context, logger *slog.Logger, reportDoc domain.ReportDoc, files []reportFile) {
+	if reportDoc.Delivery != nil && reportDoc.Delivery.Status == "sent" {
+		return
+	}
+	delivery := domain.Delivery{Recipients: reportDoc.Recipients}
+	defer func() {
+		if err := h.repo.UpdateReportDelivery(ctx, reportDoc.ID, delivery); err != nil {
+			logger.Error("Gagal mencatat status pengiriman email", "err", err)
+		}
+	}()
+	if h.mailer == nil {
+		delivery.Status, delivery.Error = "skipped", "SMTP is not configured"
+		logger.Warn("Laporan memiliki penerima tetapi SMTP belum dikonfigurasi")
+		return
+	}
+
+	l, orgName := localeFrom(ctx), themeFrom(ctx).OrgName
+	title := l.Sprintf("Laporan %s", reportDoc.Type)
+	if t, ok := reportTitles[reportDoc.Type]; ok {
+		title = l.Sprintf(t)
+	}
+	msg := mail.Message{
+		To:      reportDoc.Recipients,
+		Subject: fmt.Sprintf("%s - %s", title, orgName),
+	}
+	size := 0
+	for _, file := range files {
+		size += len(file.Content)
+	}
+	delivery.Mode = "attach"
+	if reportDoc.EmailMode == "link" || size > maxAttachmentSize {
+		delivery.Mode = "link"
+		var links strings.Builder
+		for _, file := range files {
+			links.WriteString(file.URL + "\n")
+		}
+		msg.Body = l.Sprintf("Halo,\n\n%s sudah selesai dibuat dan dapat diunduh di:\n%s\nSalam,\n%s\n", title, links.String(), orgName)
+	} else {
+		msg.Body = l.Sprintf("Halo,\n\nTerlampir %s.\n\nSalam,\n%s\n", title, orgName)
+		for _, file := range files {
+			msg.Attachments = append(msg.Attachments, mail.Attachment{Filename: file.Filename, ContentType: file.ContentType, Data: file.Content})
+		}
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	if err := h.mailer.Send(sendCtx, msg); err != nil {
+		delivery.Status, delivery.Error = "failed", err.Error()
+		logger.Error("Gagal mengirim laporan lewat email", "recipients", len(reportDoc.Recipients), "err", err)
+		return
+	}
+	delivery.Status, delivery.SentAt = "sent", time.Now()
+	logger.Info("Laporan dikirim lewat email", "recipients", len(reportDoc.Recipients), "mode", delivery.Mode)
+}
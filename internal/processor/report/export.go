@@ -0,0 +1,182 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"org-worker/internal/domain"
+)
+
+// exporter writes the raw data behind a report in a machine-readable form.
+type exporter struct {
+	suffix string
+	write  func(reportDoc domain.ReportDoc, data any) (*bytes.Buffer, error)
+}
+
+// exporters are selected by the exports list on the reports document.
+var exporters = map[string]exporter{
+	"csv":  {suffix: "-data.zip", write: exportCSVZip},
+	"json": {suffix: "-data.json", write: exportJSON},
+}
+
+// saveExports stores every requested export next to the report file and
+// returns their URLs keyed by export name.
+func (h *ReportHandler) saveExports(ctx context.Context, reportDoc domain.ReportDoc, data any) (map[string]string, error) {
+	urls := make(map[string]string, len(reportDoc.Exports))
+	for _, name := range reportDoc.Exports {
+		exp := exporters[name]
+		buf, err := exp.write(reportDoc, data)
+		if err != nil {
+			return nil, fmt.Errorf("ekspor %s gagal: %w", name, err)
+		}
+		filename := fmt.Sprintf("%s-%s%s", reportDoc.Type, reportDoc.ID, exp.suffix)
+		url, err := h.storage.Save(ctx, reportDoc.Type, filename, buf)
+		if err != nil {
+			return nil, err
+		}
+		urls[name] = url
+	}
+	return urls, nil
+}
+
+// exportJSON writes the report data together with what produced it.
+func exportJSON(reportDoc domain.ReportDoc, data any) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(struct {
+		ReportID    string                 `json:"reportID"`
+		ReportType  string                 `json:"reportType"`
+		Filters     map[string]interface{} `json:"filters"`
+		GeneratedAt time.Time              `json:"generatedAt"`
+		Data        any                    `json:"data"`
+	}{reportDoc.ID.Hex(), reportDoc.Type, reportDoc.Filters, time.Now().UTC(), data})
+	return &buf, err
+}
+
+// exportCSVZip writes one CSV per slice field of the data struct (named
+// after its JSON key) plus summary.csv with the remaining scalar fields, and
+// zips them together. Working from the struct keeps the files in step with
+// whatever the repository returns.
+func exportCSVZip(reportDoc domain.ReportDoc, data any) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported export data %T", data)
+	}
+	summary := [][]string{{"field", "value"}}
+	if err := exportFields(zw, "", v, &summary); err != nil {
+		return nil, err
+	}
+	if err := writeCSV(zw, "summary.csv", summary); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// exportFields writes the slice fields of v as CSV files and adds the rest
+// to summary. Nested structs such as the comparison period are flattened
+// with their field name as prefix ("comparison.totalIncome",
+// "comparison.stats.csv").
+func exportFields(zw *zip.Writer, prefix string, v reflect.Value, summary *[][]string) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := prefix + jsonName(field)
+		value := v.Field(i)
+		if value.Kind() == reflect.Pointer && value.Type().Elem().Kind() == reflect.Struct {
+			if value.IsNil() {
+				continue
+			}
+			if err := exportFields(zw, name+".", value.Elem(), summary); err != nil {
+				return err
+			}
+			continue
+		}
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Struct {
+			if err := writeCSV(zw, name+".csv", structRows(value)); err != nil {
+				return err
+			}
+			continue
+		}
+		*summary = append(*summary, []string{name, csvValue(value)})
+	}
+	return nil
+}
+
+// structRows turns a slice of structs into a header row plus one row per
+// element.
+func structRows(slice reflect.Value) [][]string {
+	elem := slice.Type().Elem()
+	header := make([]string, 0, elem.NumField())
+	for i := 0; i < elem.NumField(); i++ {
+		if elem.Field(i).IsExported() {
+			header = append(header, jsonName(elem.Field(i)))
+		}
+	}
+	rows := [][]string{header}
+	for i := 0; i < slice.Len(); i++ {
+		item := slice.Index(i)
+		row := make([]string, 0, len(header))
+		for j := 0; j < elem.NumField(); j++ {
+			if elem.Field(j).IsExported() {
+				row = append(row, csvValue(item.Field(j)))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func writeCSV(zw *zip.Writer, name string, rows [][]string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func jsonName(field reflect.StructField) string {
+	if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+		return tag
+	}
+	return field.Name
+}
+
+// csvValue formats a field for a CSV cell; times use RFC 3339 and lists are
+// joined with "; ".
+func csvValue(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = csvValue(v.Index(i))
+		}
+		return strings.Join(parts, "; ")
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,191 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
+	"github.com/johnfercher/maroto/v2/pkg/core"
+	"github.com/johnfercher/maroto/v2/pkg/core/entity"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// fontFamily is a TrueType family loaded from the font directory. Every
+// style is present; styles without their own file reuse the closest one.
+type fontFamily struct {
+	styles map[fontstyle.Type][]byte
+	// regular is the parsed regular face, used for glyph lookups and charts.
+	regular *truetype.Font
+}
+
+var (
+	customFonts = map[string]*fontFamily{}
+
+	// FontFallback lists the families tried, in order, after the theme's
+	// font for text it cannot draw. The built-in arial is always last.
+	FontFallback []string
+)
+
+// fontStyleSuffixes maps the file name suffix of a font file to its style.
+var fontStyleSuffixes = map[string]fontstyle.Type{
+	"regular":    fontstyle.Normal,
+	"bold":       fontstyle.Bold,
+	"italic":     fontstyle.Italic,
+	"bolditalic": fontstyle.BoldItalic,
+}
+
+// LoadFonts registers every TrueType family in dir. Files are named
+// <Family>-<Style>.ttf with Style one of Regular, Bold, Italic or
+// BoldItalic ("NotoSans-Bold.ttf"); a file without a style suffix is the
+// regular face. Families are referred to by their lower-cased name. A family
+// without a regular face is skipped and reported in the returned error.
+func LoadFonts(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.ttf"))
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]map[fontstyle.Type][]byte{}
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		family, style := name, fontstyle.Normal
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			if s, ok := fontStyleSuffixes[strings.ToLower(name[i+1:])]; ok {
+				family, style = name[:i], s
+			}
+		}
+		family = strings.ToLower(family)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if files[family] == nil {
+			files[family] = map[fontstyle.Type][]byte{}
+		}
+		files[family][style] = data
+	}
+
+	var errs []error
+	var loaded []string
+	for family, styles := range files {
+		regular, ok := styles[fontstyle.Normal]
+		if !ok {
+			errs = append(errs, fmt.Errorf("font %s tidak memiliki file Regular", family))
+			continue
+		}
+		parsed, err := truetype.Parse(regular)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("font %s tidak valid: %w", family, err))
+			continue
+		}
+		fillStyle(styles, fontstyle.Bold, fontstyle.Normal)
+		fillStyle(styles, fontstyle.Italic, fontstyle.Normal)
+		fillStyle(styles, fontstyle.BoldItalic, fontstyle.Bold)
+		customFonts[family] = &fontFamily{styles: styles, regular: parsed}
+		loaded = append(loaded, family)
+	}
+	slices.Sort(loaded)
+	return loaded, errors.Join(errs...)
+}
+
+func fillStyle(styles map[fontstyle.Type][]byte, style, from fontstyle.Type) {
+	if _, ok := styles[style]; !ok {
+		styles[style] = styles[from]
+	}
+}
+
+// knownFont reports whether family can be used in a PDF.
+func knownFont(family string) bool {
+	_, custom := customFonts[family]
+	return custom || slices.Contains(pdfFonts, family)
+}
+
+// customFontEntities returns every loaded family for Maroto to embed.
+func customFontEntities() []*entity.CustomFont {
+	var fonts []*entity.CustomFont
+	for family, f := range customFonts {
+		for style, data := range f.styles {
+			fonts = append(fonts, &entity.CustomFont{Family: family, Style: style, Bytes: data})
+		}
+	}
+	return fonts
+}
+
+// fontCovers reports whether family has a glyph for every visible character
+// of s. The built-in fonts only cover the Windows-1252 character set.
+func fontCovers(family, s string) bool {
+	f := customFonts[family]
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			continue
+		}
+		if f != nil {
+			if f.regular.Index(r) == 0 {
+				return false
+			}
+		} else if _, ok := charmap.Windows1252.EncodeRune(r); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// fontChain is the theme's font, then FontFallback, then arial. FontFallback
+// is clipped first so concurrent renders never append into its spare capacity.
+func (t *Theme) fontChain() []string {
+	chain := []string{t.Font}
+	for _, family := range append(slices.Clip(FontFallback), DefaultTheme.Font) {
+		if knownFont(family) && !slices.Contains(chain, family) {
+			chain = append(chain, family)
+		}
+	}
+	return chain
+}
+
+// fontFor returns the first family in the fallback chain that can draw all
+// of s, or the theme's font when none can.
+func (t *Theme) fontFor(s string) string {
+	for _, family := range t.fontChain() {
+		if fontCovers(family, s) {
+			return family
+		}
+	}
+	return t.Font
+}
+
+// chartFont returns the first loaded TrueType family in the fallback chain
+// that can draw every label, or nil to keep go-chart's own font.
+func (t *Theme) chartFont(labels ...string) *truetype.Font {
+	all := strings.Join(labels, "")
+	for _, family := range t.fontChain() {
+		if f, ok := customFonts[family]; ok && fontCovers(family, all) {
+			return f.regular
+		}
+	}
+	return nil
+}
+
+// text is text.New with the font picked by fontFor.
+func (t *Theme) text(value string, p props.Text) core.Component {
+	p.Family = t.fontFor(value)
+	return text.New(value, p)
+}
+
+// textCol is text.NewCol with the font picked by fontFor.
+func (t *Theme) textCol(size int, value string, p props.Text) core.Col {
+	p.Family = t.fontFor(value)
+	return text.NewCol(size, value, p)
+}
+
+// textRow is text.NewRow with the font picked by fontFor.
+func (t *Theme) textRow(height float64, value string, p props.Text) core.Row {
+	p.Family = t.fontFor(value)
+	return text.NewRow(height, value, p)
+}
@@ -0,0 +1,146 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/base64"
+	"html/template"
+	"time"
+
+	"github.com/johnfercher/maroto/v2/pkg/props"
+)
+
+//go:embed templates/report.html
+var htmlTemplates embed.FS
+
+var htmlReportTemplate = template.Must(template.ParseFS(htmlTemplates, "templates/report.html"))
+
+// htmlPage is the HTML counterpart of a Maroto document: the header from
+// GetMarotoInstance followed by the sections of the report layout. Images
+// are inlined as data URIs so the file stands alone.
+type htmlPage struct {
+	Lang      string
+	OrgName   string
+	Logo      template.URL
+	Title     string
+	Subtitle  string
+	Footer    string
+	Generated string
+	Colors    htmlColors
+	Sections  []htmlSection
+}
+
+type htmlColors struct {
+	Primary, Secondary, BgLight, TextMain, TextMute string
+}
+
+type htmlSection struct {
+	Title  string
+	Cards  []summaryCard
+	Charts []htmlChart
+	Lines  []string
+	Table  *htmlTable
+	Items  []htmlItem
+	// Empty is shown when the section has nothing else to show.
+	Empty string
+}
+
+type htmlChart struct {
+	Src  template.URL
+	Wide bool
+}
+
+type htmlTable struct {
+	Headers []string
+	// Aligns holds the CSS text-align of every column.
+	Aligns []string
+	Rows   [][]string
+}
+
+type htmlItem struct {
+	Heading string
+	Meta    string
+	Text    string
+	Photos  []template.URL
+	Notes   []string
+}
+
+func newHTMLPage(l *localizer, th *Theme, title, subtitle string) *htmlPage {
+	css := func(c *props.Color) string { return "#" + hexColor(c) }
+	page := &htmlPage{
+		Lang:      l.tag.String(),
+		OrgName:   th.OrgName,
+		Title:     title,
+		Subtitle:  subtitle,
+		Footer:    th.footer(),
+		Generated: l.Sprintf("Dibuat %s", l.Date(time.Now())),
+		Colors: htmlColors{
+			Primary:   css(th.Primary),
+			Secondary: css(th.Secondary),
+			BgLight:   css(th.BgLight),
+			TextMain:  css(th.TextMain),
+			TextMute:  css(th.TextMute),
+		},
+	}
+	if th.Logo != nil {
+		page.Logo = dataURI("image/png", th.Logo)
+	}
+	return page
+}
+
+func (p *htmlPage) add(section htmlSection) {
+	p.Sections = append(p.Sections, section)
+}
+
+func (p *htmlPage) render() (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	if err := htmlReportTemplate.Execute(&buf, p); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+func dataURI(mime string, data []byte) template.URL {
+	return template.URL("data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data))
+}
+
+// TemplateHTML renders any report type as a standalone HTML page using the
+// report template carried by ctx.
+func TemplateHTML[T any](ctx context.Context, data T) (*bytes.Buffer, error) {
+	layout, err := buildLayout(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	page := newHTMLPage(localeFrom(ctx), themeFrom(ctx), layout.Title, layout.Subtitle)
+	for _, section := range layout.Sections {
+		page.add(newHTMLSection(section))
+	}
+	return page.render()
+}
+
+func newHTMLSection(section layoutSection) htmlSection {
+	out := htmlSection{Title: section.Title, Cards: section.Cards, Lines: section.Lines, Empty: section.Empty}
+	for _, chart := range section.Charts {
+		out.Charts = append(out.Charts, htmlChart{Src: dataURI("image/png", chart.PNG), Wide: chart.Wide})
+	}
+	if table := section.Table; table != nil {
+		out.Table = &htmlTable{Rows: table.Rows}
+		for _, column := range table.Columns {
+			out.Table.Headers = append(out.Table.Headers, column.Header)
+			out.Table.Aligns = append(out.Table.Aligns, column.Align)
+		}
+	}
+	for _, item := range section.Items {
+		converted := htmlItem{Heading: item.Heading, Meta: item.Meta, Text: item.Text}
+		if item.TextNote != "" {
+			converted.Notes = append(converted.Notes, item.TextNote)
+		}
+		for _, photo := range item.Photos {
+			converted.Photos = append(converted.Photos, dataURI("image/jpeg", photo))
+		}
+		converted.Notes = append(converted.Notes, item.Notes...)
+		out.Items = append(out.Items, converted)
+	}
+	return out
+}
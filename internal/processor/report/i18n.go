@@ -0,0 +1,228 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"org-worker/internal/retry"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// Report text is written in Indonesian and the Indonesian strings double as
+// message keys, so a missing translation falls back to the original label.
+// Numbers in every message are formatted for the printer's language.
+
+// DefaultLocale is used when a report has no locale filter.
+var DefaultLocale = language.Indonesian
+
+var supportedLocales = []language.Tag{language.Indonesian, language.English}
+
+var localeMatcher = language.NewMatcher(supportedLocales)
+
+// englishMessages translates every label used by the report renderers.
+var englishMessages = map[string]string{
+	// Titles and headers shared by every format.
+	"Laporan Aktivitas Komunitas":            "Community Activity Report",
+	"Laporan Demografi Peserta":              "Participant Demographics Report",
+	"Laporan Dampak Program":                 "Program Impact Report",
+	"Laporan Transparansi Keuangan":          "Financial Transparency Report",
+	"Laporan %s":                             "%s Report",
+	"Komunitas: %s | Periode: %s - %s":       "Community: %s | Period: %s - %s",
+	"Komunitas: %s | Total Peserta: %d":      "Community: %s | Total Participants: %d",
+	"Periode: %s s.d. %s":                    "Period: %s to %s",
+	"Dibuat %s":                              "Generated %s",
+	"+%d metrik lainnya":                     "+%d more metrics",
+	"Tidak berubah":                          "No change",
+	"Dibandingkan dengan periode %s - %s":    "Compared with %s - %s",
+	"Dibandingkan dengan periode %s s.d. %s": "Compared with %s to %s",
+
+	// Community activity.
+	"Ringkasan Kinerja":             "Performance Summary",
+	"Anggota Baru":                  "New Members",
+	"Anggota Aktif":                 "Active Members",
+	"Total Kegiatan":                "Total Activities",
+	"%d Orang":                      "%d People",
+	"%d Kegiatan":                   "%d Activities",
+	"Detail Kegiatan & Dokumentasi": "Activity Details & Documentation",
+	"Tren Aktivitas":                "Activity Trend",
+	"Kehadiran Peserta":             "Attendance",
+	"Kegiatan Terlaksana":           "Activities Held",
+	"Tidak ada kegiatan yang tercatat pada periode ini.":  "No activities were recorded in this period.",
+	"Tanggal: %s   |   Fasilitator: %s   |   Peserta: %d": "Date: %s   |   Facilitator: %s   |   Participants: %d",
+	"(Tidak ada foto dokumentasi)":                        "(No documentation photos)",
+	"(+%d foto dokumentasi lainnya)":                      "(+%d more documentation photos)",
+
+	// Participant demographics.
+	"Ringkasan Laporan":            "Report Summary",
+	"Total Peserta":                "Total Participants",
+	"Status yang Dipantau":         "Statuses Tracked",
+	"Lokasi yang Dipantau":         "Locations Tracked",
+	"%d Segmen":                    "%d Segments",
+	"%d Wilayah":                   "%d Regions",
+	"Berdasarkan Status Pekerjaan": "By Employment Status",
+	"Berdasarkan Kelompok Usia":    "By Age Group",
+	"Berdasarkan Lokasi (Top 10)":  "By Location (Top 10)",
+	"Grafik Distribusi":            "Distribution Charts",
+	"Tidak dapat menampilkan grafik tanpa total peserta.": "Charts cannot be shown without a participant total.",
+	"Grafik tidak dapat dibuat.":                          "The charts could not be generated.",
+	"Tidak ada data untuk kategori ini.":                  "No data for this category.",
+	"Tidak Ditentukan":                                    "Unspecified",
+
+	// Program impact.
+	"Proyek Diajukan":              "Projects Submitted",
+	"Level Up":                     "Level Ups",
+	"Penempatan Kerja":             "Job Placements",
+	"%d Proyek":                    "%d Projects",
+	"%d Anggota":                   "%d Members",
+	"%d Penempatan":                "%d Placements",
+	"Sorotan Dampak & Dokumentasi": "Impact Highlights & Documentation",
+	"Tidak ada sorotan dampak yang tercatat pada periode ini.": "No impact highlights were recorded in this period.",
+	"Grafik Distribusi Pencapaian":                             "Milestone Distribution Chart",
+	"Tidak ada data milestone untuk divisualisasikan.":         "No milestone data to visualise.",
+	"Penanggung Jawab: %s":                                     "Owner: %s",
+	"Penanggung Jawab":                                         "Owner",
+	"(Tidak ada deskripsi sorotan)":                            "(No highlight description)",
+
+	// Financial summary.
+	"Ringkasan Keuangan":                    "Financial Summary",
+	"Total Pemasukan":                       "Total Income",
+	"Total Pengeluaran":                     "Total Expenses",
+	"Saldo Bersih":                          "Net Balance",
+	"Donasi barang tercatat: %s":            "Recorded in-kind donations: %s",
+	"Alokasi Pengeluaran":                   "Expense Allocation",
+	"5 Donasi Tunai Teratas":                "Top 5 Cash Donations",
+	"Sumber":                                "Source",
+	"Tanggal":                               "Date",
+	"Jumlah":                                "Total",
+	"Tidak ada donasi tunai yang tercatat.": "No cash donations were recorded.",
+	"Tidak ada pengeluaran yang tercatat pada periode ini.": "No expenses were recorded in this period.",
+
+	// Spreadsheet sheets and columns.
+	"Ringkasan":                "Summary",
+	"Keterangan":               "Item",
+	"Nilai":                    "Value",
+	"Komunitas":                "Community",
+	"Periode Mulai":            "Period Start",
+	"Periode Selesai":          "Period End",
+	"Donasi Barang (Estimasi)": "In-Kind Donations (Estimated)",
+	"Pengeluaran":              "Expenses",
+	"Kategori":                 "Category",
+	"Persentase":               "Share",
+	"Pemasukan":                "Income",
+	"Pemasukan per Sumber":     "Income by Source",
+	"Donasi Teratas":           "Top Donations",
+	"Kegiatan":                 "Activity",
+	"Fasilitator":              "Facilitator",
+	"Peserta":                  "Participants",
+	"Dokumentasi":              "Documentation",
+	"Usia":                     "Age",
+	"Kelompok Usia":            "Age Group",
+	"Lokasi":                   "Location",
+	"Pencapaian":               "Milestones",
+	"Judul":                    "Title",
+	"Sorotan":                  "Highlights",
+	"Tren":                     "Trend",
+	"Mulai":                    "Start",
+	"Perbandingan":             "Comparison",
+	"Periode Ini":              "This Period",
+	"Pembanding":               "Compared Period",
+	"Selisih":                  "Difference",
+	"Perubahan":                "Change",
+
+	// Email delivery.
+	"Halo,\n\n%s sudah selesai dibuat dan dapat diunduh di:\n%s\nSalam,\n%s\n": "Hello,\n\nThe %s is ready and can be downloaded at:\n%s\nRegards,\n%s\n",
+	"Halo,\n\nTerlampir %s.\n\nSalam,\n%s\n":                                   "Hello,\n\nPlease find the %s attached.\n\nRegards,\n%s\n",
+}
+
+var reportCatalog = func() catalog.Catalog {
+	b := catalog.NewBuilder(catalog.Fallback(DefaultLocale))
+	for key, msg := range englishMessages {
+		if err := b.SetString(language.English, key, msg); err != nil {
+			panic(fmt.Sprintf("invalid report message %q: %v", key, err))
+		}
+	}
+	return b
+}()
+
+var indonesianMonths = [...]string{"Jan", "Feb", "Mar", "Apr", "Mei", "Jun", "Jul", "Agu", "Sep", "Okt", "Nov", "Des"}
+
+// localizer formats report text, dates and amounts for one language.
+type localizer struct {
+	tag     language.Tag
+	printer *message.Printer
+}
+
+func newLocalizer(tag language.Tag) *localizer {
+	return &localizer{tag: tag, printer: message.NewPrinter(tag, message.Catalog(reportCatalog))}
+}
+
+// Sprintf translates key and formats args for the localizer's language.
+func (l *localizer) Sprintf(key string, args ...any) string {
+	return l.printer.Sprintf(key, args...)
+}
+
+// Date formats t as "02 Jan 2006" with the month name in the report language.
+func (l *localizer) Date(t time.Time) string {
+	if l.tag == language.Indonesian {
+		return fmt.Sprintf("%02d %s %d", t.Day(), indonesianMonths[t.Month()-1], t.Year())
+	}
+	return t.Format("02 Jan 2006")
+}
+
+// Month formats t as "Jan 2006" with the month name in the report language.
+func (l *localizer) Month(t time.Time) string {
+	if l.tag == language.Indonesian {
+		return fmt.Sprintf("%s %d", indonesianMonths[t.Month()-1], t.Year())
+	}
+	return t.Format("Jan 2006")
+}
+
+// Day formats t as "02 Jan", for labels within a single report period.
+func (l *localizer) Day(t time.Time) string {
+	if l.tag == language.Indonesian {
+		return fmt.Sprintf("%02d %s", t.Day(), indonesianMonths[t.Month()-1])
+	}
+	return t.Format("02 Jan")
+}
+
+// Money formats an amount in rupiah with the language's digit grouping.
+func (l *localizer) Money(val float64) string {
+	return l.printer.Sprintf("Rp %.0f", val)
+}
+
+// reportLocale reads the locale filter ("id", "en", "en-US", ...). An empty
+// filter means DefaultLocale; a language without translations is rejected.
+func reportLocale(filters map[string]interface{}) (language.Tag, error) {
+	raw, _ := filters["locale"].(string)
+	if raw == "" {
+		return DefaultLocale, nil
+	}
+	requested, err := language.Parse(raw)
+	if err != nil {
+		return language.Und, retry.Permanent(fmt.Errorf("locale tidak valid: %s", raw))
+	}
+	_, index, confidence := localeMatcher.Match(requested)
+	if confidence == language.No {
+		return language.Und, retry.Permanent(fmt.Errorf("locale tidak didukung: %s", raw))
+	}
+	return supportedLocales[index], nil
+}
+
+type localeKey struct{}
+
+// withLocale attaches the report language to the job context so every
+// renderer can pick it up.
+func withLocale(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, localeKey{}, newLocalizer(tag))
+}
+
+func localeFrom(ctx context.Context) *localizer {
+	if l, ok := ctx.Value(localeKey{}).(*localizer); ok {
+		return l
+	}
+	return newLocalizer(DefaultLocale)
+}
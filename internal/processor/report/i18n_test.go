@@ -0,0 +1,71 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"org-worker/internal/retry"
+
+	"golang.org/x/text/language"
+)
+
+func TestReportLocale(t *testing.T) {
+	tests := []struct {
+		locale  any
+		want    language.Tag
+		wantErr bool
+	}{
+		{nil, language.Indonesian, false},
+		{"", language.Indonesian, false},
+		{"id", language.Indonesian, false},
+		{"id-ID", language.Indonesian, false},
+		{"en", language.English, false},
+		{"en-US", language.English, false},
+		{"en-GB", language.English, false},
+		{"fr", language.Und, true},
+		{"not a tag!", language.Und, true},
+	}
+	for _, tt := range tests {
+		filters := map[string]interface{}{}
+		if tt.locale != nil {
+			filters["locale"] = tt.locale
+		}
+		got, err := reportLocale(filters)
+		if tt.wantErr {
+			if err == nil || retry.IsRetryable(err) {
+				t.Errorf("reportLocale(%v): err %v, want a permanent error", tt.locale, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("reportLocale(%v) = %v, %v; want %v", tt.locale, got, err, tt.want)
+		}
+	}
+}
+
+func TestLocalizerFormats(t *testing.T) {
+	may := time.Date(2025, 5, 7, 0, 0, 0, 0, time.UTC)
+	id, en := newLocalizer(language.Indonesian), newLocalizer(language.English)
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"id date", id.Date(may), "07 Mei 2025"},
+		{"en date", en.Date(may), "07 May 2025"},
+		{"id month", id.Month(may), "Mei 2025"},
+		{"en month", en.Month(may), "May 2025"},
+		{"id day", id.Day(may), "07 Mei"},
+		{"en day", en.Day(may), "07 May"},
+		{"id money", id.Money(1250000), "Rp 1.250.000"},
+		{"en money", en.Money(1250000), "Rp 1,250,000"},
+		{"translated", en.Sprintf("Laporan Dampak Program"), "Program Impact Report"},
+		{"translated with args", en.Sprintf("Laporan %s", "Kustom"), "Kustom Report"},
+		{"untranslated key", en.Sprintf("Tidak ada di katalog"), "Tidak ada di katalog"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
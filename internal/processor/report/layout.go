@@ -0,0 +1,389 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"org-worker/internal/domain"
+	"org-worker/internal/progress"
+	"org-worker/internal/retry"
+)
+
+// reportLayout is a template evaluated against the report data. Everything
+// the PDF and HTML renderers draw is already translated and formatted, and
+// charts and photos are already downloaded or rendered.
+type reportLayout struct {
+	Title    string
+	Subtitle string
+	Sections []layoutSection
+}
+
+type layoutSection struct {
+	Title  string
+	Cards  []summaryCard
+	Charts []layoutChart
+	Lines  []string
+	Table  *layoutTable
+	Items  []layoutItem
+	// Empty is shown when the section has nothing else to show.
+	Empty string
+}
+
+type layoutChart struct {
+	// PNG is the rendered chart.
+	PNG []byte
+	// Wide charts take a full row instead of sharing it.
+	Wide bool
+}
+
+type layoutTable struct {
+	Columns []layoutColumn
+	Rows    [][]string
+}
+
+type layoutColumn struct {
+	Header string
+	Width  int
+	Align  string
+}
+
+type layoutItem struct {
+	Heading string
+	Meta    string
+	Text    string
+	// TextNote stands in for a blank Text.
+	TextNote string
+	// Photos are JPEG images.
+	Photos [][]byte
+	Notes  []string
+}
+
+// buildLayout evaluates the template carried by ctx against data. Errors in
+// the template are permanent: retrying renders the same template again.
+func buildLayout(ctx context.Context, data any) (*reportLayout, error) {
+	tpl := templateFrom(ctx)
+	if tpl == nil {
+		return nil, retry.Permanent(fmt.Errorf("template laporan tidak ditemukan"))
+	}
+	l, th := localeFrom(ctx), themeFrom(ctx)
+	s := newScope(l, data)
+	layout, err := evaluateLayout(ctx, th, s, tpl)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, retry.Permanent(fmt.Errorf("template %s: %w", tpl.Name, err))
+	}
+	progress.Report(ctx, progress.StageRendering, 85, "rendering")
+	return layout, nil
+}
+
+func evaluateLayout(ctx context.Context, th *Theme, s scope, tpl *domain.ReportTemplate) (*reportLayout, error) {
+	subtitle, err := s.text(tpl.Subtitle)
+	if err != nil {
+		return nil, err
+	}
+	layout := &reportLayout{Title: s.l.Sprintf(tpl.Title), Subtitle: subtitle}
+	for _, ts := range tpl.Sections {
+		if ts.When != "" {
+			skip, err := s.empty(ts.When)
+			if err != nil {
+				return nil, err
+			}
+			if skip {
+				continue
+			}
+		}
+		section, err := evaluateSection(ctx, th, s, ts)
+		if err != nil {
+			return nil, err
+		}
+		layout.Sections = append(layout.Sections, section)
+	}
+	return layout, nil
+}
+
+func evaluateSection(ctx context.Context, th *Theme, s scope, ts domain.TemplateSection) (layoutSection, error) {
+	section := layoutSection{Title: s.l.Sprintf(ts.Title)}
+	if ts.EmptyIf != "" {
+		empty, err := s.empty(ts.EmptyIf)
+		if err != nil {
+			return section, err
+		}
+		if empty {
+			section.Empty = s.l.Sprintf(ts.Empty)
+			return section, nil
+		}
+	}
+
+	for _, tc := range ts.Cards {
+		value, err := s.text(tc.Value)
+		if err != nil {
+			return section, err
+		}
+		card := summaryCard{Label: s.l.Sprintf(tc.Label), Value: value}
+		if tc.Change != nil && s.present(tc.Change.Previous) {
+			if card.Change, card.Trend, card.Good, err = s.change(tc.Change); err != nil {
+				return section, err
+			}
+		}
+		section.Cards = append(section.Cards, card)
+	}
+
+	for _, chart := range ts.Charts {
+		image, err := evaluateChart(s, th, chart)
+		if err != nil {
+			return section, err
+		}
+		if image != nil {
+			section.Charts = append(section.Charts, layoutChart{PNG: image, Wide: chart.Kind == "line"})
+		}
+	}
+	if len(ts.Charts) > 0 && len(section.Charts) == 0 {
+		chartError := ts.ChartError
+		if chartError == "" {
+			chartError = "Grafik tidak dapat dibuat."
+		}
+		section.Empty = s.l.Sprintf(chartError)
+	}
+
+	for _, lines := range ts.Lines {
+		if lines.When != "" {
+			empty, err := s.empty(lines.When)
+			if err != nil {
+				return section, err
+			}
+			if empty {
+				continue
+			}
+		}
+		err := eachItem(s, lines.Source, func(item scope) error {
+			line, err := item.text(lines.Text)
+			section.Lines = append(section.Lines, line)
+			return err
+		})
+		if err != nil {
+			return section, err
+		}
+	}
+
+	if table := ts.Table; table != nil {
+		section.Table = &layoutTable{}
+		widths := columnWidths(table.Columns)
+		for i, column := range table.Columns {
+			section.Table.Columns = append(section.Table.Columns, layoutColumn{Header: s.l.Sprintf(column.Header), Width: widths[i], Align: column.Align})
+		}
+		err := eachItem(s, table.Source, func(item scope) error {
+			row := make([]string, 0, len(table.Columns))
+			for _, column := range table.Columns {
+				cell, err := item.text(column.Value)
+				if err != nil {
+					return err
+				}
+				row = append(row, cell)
+			}
+			section.Table.Rows = append(section.Table.Rows, row)
+			return nil
+		})
+		if err != nil {
+			return section, err
+		}
+	}
+
+	if ts.Items != nil {
+		items, err := evaluateItems(ctx, s, ts.Items)
+		if err != nil {
+			return section, err
+		}
+		section.Items = items
+	}
+	return section, nil
+}
+
+// columnWidths keeps the explicit widths and splits what is left of the
+// 12-column grid over the other columns, at least 1 each. Leftover units
+// go to the first unsized columns so the row stays full.
+func columnWidths(columns []domain.TemplateColumn) []int {
+	widths := make([]int, len(columns))
+	free, unsized := 12, 0
+	for i, column := range columns {
+		widths[i] = column.Width
+		if column.Width > 0 {
+			free -= column.Width
+		} else {
+			unsized++
+		}
+	}
+	if unsized == 0 {
+		return widths
+	}
+	share, extra := 1, 0
+	if free > unsized {
+		share, extra = free/unsized, free%unsized
+	}
+	for i := range widths {
+		if widths[i] > 0 {
+			continue
+		}
+		widths[i] = share
+		if extra > 0 {
+			widths[i]++
+			extra--
+		}
+	}
+	return widths
+}
+
+// eachItem calls fn for every entry of the list at source, or once with s
+// itself when source is empty.
+func eachItem(s scope, source string, fn func(item scope) error) error {
+	if source == "" {
+		return fn(s)
+	}
+	list, err := s.list(source)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < list.Len(); i++ {
+		if err := fn(s.at(list.Index(i), i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluateChart renders one chart. A chart that fails to draw is logged and
+// left out rather than failing the report.
+func evaluateChart(s scope, th *Theme, tc domain.TemplateChart) ([]byte, error) {
+	label, value := tc.Label, tc.Value
+	if label == "" {
+		label = "id"
+	}
+	if value == "" {
+		value = "count"
+	}
+	var points []chartPoint
+	err := eachItem(s, tc.Source, func(item scope) error {
+		name, err := item.arg(label)
+		if err != nil {
+			return err
+		}
+		v, err := item.number(value)
+		if err != nil {
+			return err
+		}
+		points = append(points, chartPoint{Label: fmt.Sprint(name), Value: v})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var image []byte
+	switch tc.Kind {
+	case "pie":
+		var total float64
+		if tc.Total != "" {
+			if total, err = s.number(tc.Total); err != nil {
+				return nil, err
+			}
+		} else {
+			for _, p := range points {
+				total += p.Value
+			}
+		}
+		image, err = createPieChartImage(s.l, th, points, total)
+	case "bar":
+		image, err = createBarChartImage(th, points, s.l.Sprintf(tc.Title))
+	case "line":
+		image, err = createLineChartImage(th, points, s.l.Sprintf(tc.Title))
+	}
+	if err != nil {
+		slog.Warn("Gagal membuat grafik", "kind", tc.Kind, "title", tc.Title, "err", err)
+		return nil, nil
+	}
+	return image, nil
+}
+
+// evaluateItems builds the item blocks and downloads their photos, up to
+// maxPhotosPerItem each.
+func evaluateItems(ctx context.Context, s scope, ti *domain.TemplateItems) ([]layoutItem, error) {
+	list, err := s.list(ti.Source)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([][]string, list.Len())
+	if ti.Photos != "" {
+		for i := range urls {
+			v, err := s.at(list.Index(i), i).value(ti.Photos)
+			if err != nil {
+				return nil, err
+			}
+			photoURLs, ok := v.Interface().([]string)
+			if !ok {
+				return nil, fmt.Errorf("field template %s bukan daftar URL", ti.Photos)
+			}
+			urls[i] = photoURLs
+		}
+	}
+	counts := make([]int, len(urls))
+	for i, u := range urls {
+		counts[i] = len(u)
+	}
+	photos := newPhotoProgress(counts...)
+
+	items := make([]layoutItem, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		s := s.at(list.Index(i), i)
+		var item layoutItem
+		if item.Heading, err = s.text(ti.Heading); err != nil {
+			return nil, err
+		}
+		if item.Meta, err = s.text(ti.Meta); err != nil {
+			return nil, err
+		}
+		if ti.Text != "" {
+			text, err := s.arg(ti.Text)
+			if err != nil {
+				return nil, err
+			}
+			item.Text = strings.TrimSpace(fmt.Sprint(text))
+			if item.Text == "" && ti.TextEmpty != "" {
+				item.TextNote = s.l.Sprintf(ti.TextEmpty)
+			}
+		}
+		if ti.Photos != "" {
+			item.Photos, item.Notes = itemPhotos(ctx, s.l, urls[i], photos)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// itemPhotos downloads up to maxPhotosPerItem photos and returns a note for
+// a missing gallery or for the photos that were left out.
+func itemPhotos(ctx context.Context, l *localizer, urls []string, photos *photoProgress) ([][]byte, []string) {
+	if len(urls) == 0 {
+		return nil, []string{l.Sprintf("(Tidak ada foto dokumentasi)")}
+	}
+	limit := min(len(urls), maxPhotosPerItem)
+	var images [][]byte
+	for _, url := range urls[:limit] {
+		imgBytes, err := downloadImageAsJPG(ctx, url)
+		photos.downloaded(ctx)
+		if err != nil {
+			continue
+		}
+		images = append(images, imgBytes)
+	}
+	var notes []string
+	if len(urls) > limit {
+		notes = append(notes, l.Sprintf("(+%d foto dokumentasi lainnya)", len(urls)-limit))
+	}
+	return images, notes
+}
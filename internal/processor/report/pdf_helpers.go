@@ -3,6 +3,8 @@ package report
 import (
 	"fmt"
 
+	"github.com/johnfercher/maroto/v2/pkg/components/col"
+	"github.com/johnfercher/maroto/v2/pkg/components/image"
 	"github.com/johnfercher/maroto/v2/pkg/components/text"
 	"github.com/johnfercher/maroto/v2/pkg/consts/align"
 	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
@@ -13,9 +15,24 @@ import (
 type summaryCard struct {
 	Label string
 	Value string
+	// Change is the movement since the comparison period, empty without one.
+	Change string
+	// Trend is 1 for an increase, -1 for a decrease and 0 for no change;
+	// Good tells whether that direction is the desired one.
+	Trend int
+	Good  bool
 }
 
-func renderSummaryCards(m core.Maroto, cards []summaryCard) {
+var (
+	colorGood = &props.Color{Red: 22, Green: 163, Blue: 74}
+	colorBad  = &props.Color{Red: 220, Green: 38, Blue: 38}
+)
+
+// trendIndicators are drawn before a card's change when a font in the
+// theme's fallback chain has them; the built-in fonts do not.
+var trendIndicators = map[int]string{1: "▲", -1: "▼"}
+
+func renderSummaryCards(m core.Maroto, l *localizer, th *Theme, cards []summaryCard) {
 	if len(cards) == 0 {
 		return
 	}
@@ -27,19 +44,52 @@ func renderSummaryCards(m core.Maroto, cards []summaryCard) {
 	cols := make([]core.Col, 0, columns)
 	for i := 0; i < columns; i++ {
 		card := cards[i]
-		cols = append(cols, text.NewCol(width, fmt.Sprintf("%s\n%s", card.Label, card.Value), props.Text{
+		c := col.New(width).Add(th.text(fmt.Sprintf("%s\n%s", card.Label, card.Value), props.Text{
 			Align: align.Center,
 			Top:   4,
 			Size:  11,
 			Style: fontstyle.Bold,
 		}))
+		if card.Change != "" {
+			c.Add(th.text(cardChange(th, card), props.Text{
+				Align: align.Center,
+				Top:   17,
+				Size:  8,
+				Color: changeColor(th, card),
+			}))
+		}
+		cols = append(cols, c)
 	}
 	row := m.AddRow(25, cols...)
-	row.WithStyle(&props.Cell{BackgroundColor: ColorBgLight})
+	row.WithStyle(&props.Cell{BackgroundColor: th.BgLight})
 	m.AddRow(4, text.NewCol(12, ""))
 
 	if len(cards) > columns {
-		more := fmt.Sprintf("+%d more metrics", len(cards)-columns)
-		m.AddRow(6, text.NewCol(12, more, props.Text{Style: fontstyle.Italic, Color: ColorTextMute}))
+		more := l.Sprintf("+%d metrik lainnya", len(cards)-columns)
+		m.AddRow(6, th.textCol(12, more, props.Text{Style: fontstyle.Italic, Color: th.TextMute}))
+	}
+}
+
+// cardChange prefixes the card's change with its trend indicator when the
+// PDF can draw it.
+func cardChange(th *Theme, card summaryCard) string {
+	if indicator, ok := trendIndicators[card.Trend]; ok && fontCovers(th.fontFor(indicator), indicator) {
+		return indicator + " " + card.Change
 	}
+	return card.Change
+}
+
+func changeColor(th *Theme, card summaryCard) *props.Color {
+	switch {
+	case card.Trend == 0:
+		return th.TextMute
+	case card.Good:
+		return colorGood
+	}
+	return colorBad
+}
+
+// logoCol draws the theme's logo in a column of the given size.
+func logoCol(th *Theme, size int) core.Col {
+	return image.NewFromBytesCol(size, th.Logo, "png", props.Rect{Percent: 100})
 }
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/johnfercher/maroto/v2/pkg/components/image"
+	"github.com/johnfercher/maroto/v2/pkg/components/line"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
+	"github.com/johnfercher/maroto/v2/pkg/consts/align"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
+	"github.com/johnfercher/maroto/v2/pkg/core"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+)
+
+// TemplatePDF renders any report type as a PDF using the report template
+// carried by ctx.
+func TemplatePDF[T any](ctx context.Context, data T) (*bytes.Buffer, error) {
+	layout, err := buildLayout(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	l, th := localeFrom(ctx), themeFrom(ctx)
+	m := GetMarotoInstance(ctx, layout.Title, layout.Subtitle)
+	for _, section := range layout.Sections {
+		renderPDFSection(m, l, th, section)
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		return nil, err
+	}
+	return marotoDocumentBuffer(document), nil
+}
+
+var pdfAligns = map[string]align.Type{"": align.Left, "left": align.Left, "center": align.Center, "right": align.Right}
+
+func renderPDFSection(m core.Maroto, l *localizer, th *Theme, section layoutSection) {
+	note := props.Text{Style: fontstyle.Italic, Color: th.TextMute}
+	addSectionTitle(m, th, section.Title)
+	renderSummaryCards(m, l, th, section.Cards)
+
+	// Charts sit two to a row, a single or wide chart gets the full width.
+	for i := 0; i < len(section.Charts); {
+		chart := section.Charts[i]
+		if chart.Wide {
+			m.AddRow(60, image.NewFromBytesCol(12, chart.PNG, "png", props.Rect{Percent: 95, Center: true}))
+			i++
+			continue
+		}
+		if i+1 == len(section.Charts) || section.Charts[i+1].Wide {
+			m.AddRow(70, image.NewFromBytesCol(12, chart.PNG, "png", props.Rect{Percent: 85, Center: true}))
+			i++
+			continue
+		}
+		m.AddRow(80,
+			image.NewFromBytesCol(6, chart.PNG, "png", props.Rect{Percent: 90, Center: true}),
+			image.NewFromBytesCol(6, section.Charts[i+1].PNG, "png", props.Rect{Percent: 90, Center: true}),
+		)
+		i += 2
+	}
+
+	if len(section.Lines) > 0 {
+		for _, lineText := range section.Lines {
+			m.AddRow(6, th.textCol(12, "- "+lineText, props.Text{Size: 10}))
+		}
+		m.AddRow(4, text.NewCol(12, ""))
+	}
+
+	if table := section.Table; table != nil {
+		header := make([]core.Col, 0, len(table.Columns))
+		for _, column := range table.Columns {
+			header = append(header, th.textCol(column.Width, column.Header, props.Text{Align: align.Center, Style: fontstyle.Bold}))
+		}
+		m.AddRow(8, header...).WithStyle(&props.Cell{BackgroundColor: th.BgLight})
+		for _, cells := range table.Rows {
+			cols := make([]core.Col, 0, len(cells))
+			for i, cell := range cells {
+				column := table.Columns[i]
+				cols = append(cols, th.textCol(column.Width, cell, props.Text{Size: 10, Align: pdfAligns[column.Align]}))
+			}
+			m.AddRow(8, cols...)
+			m.AddRow(1, line.NewCol(12))
+		}
+	}
+
+	for _, item := range section.Items {
+		m.AddRow(8, th.textCol(12, item.Heading, props.Text{
+			Style: fontstyle.Bold,
+			Size:  12,
+			Color: th.TextMain,
+		}))
+		if item.Meta != "" {
+			m.AddRow(6, th.textCol(12, item.Meta, props.Text{Size: 9, Color: th.TextMute}))
+		}
+		if item.Text != "" {
+			m.AddRow(10, th.textCol(12, item.Text, props.Text{Size: 10, Align: align.Left}))
+		} else if item.TextNote != "" {
+			m.AddRow(6, th.textCol(12, item.TextNote, note))
+		}
+		if len(item.Photos) > 0 {
+			cols := make([]core.Col, 0, len(item.Photos))
+			for _, photo := range item.Photos {
+				cols = append(cols, image.NewFromBytesCol(3, photo, "jpg", props.Rect{Percent: 95, Center: true}))
+			}
+			m.AddRow(40, cols...)
+		}
+		for _, n := range item.Notes {
+			m.AddRow(6, th.textCol(12, n, note))
+		}
+		m.AddRow(4, line.NewCol(12))
+		m.AddRow(4, text.NewCol(12, ""))
+	}
+
+	if section.Empty != "" {
+		m.AddRow(8, th.textCol(12, section.Empty, note))
+	}
+}
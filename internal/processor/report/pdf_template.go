@@ -2,15 +2,21 @@ package report
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"image"
 	"image/jpeg"
 	_ "image/png"
 	"net/http"
+	"sync"
 	"time"
 
+	"org-worker/internal/metrics"
+	"org-worker/internal/progress"
+
 	"github.com/johnfercher/maroto/v2"
 	"github.com/johnfercher/maroto/v2/pkg/components/line"
+	"github.com/johnfercher/maroto/v2/pkg/components/row"
 	"github.com/johnfercher/maroto/v2/pkg/components/text"
 	"github.com/johnfercher/maroto/v2/pkg/config"
 	"github.com/johnfercher/maroto/v2/pkg/consts/align"
@@ -19,48 +25,59 @@ import (
 	"github.com/johnfercher/maroto/v2/pkg/props"
 )
 
-var (
-	ColorPrimary   = &props.Color{Red: 30, Green: 58, Blue: 138}
-	ColorSecondary = &props.Color{Red: 59, Green: 130, Blue: 246}
-	ColorBgLight   = &props.Color{Red: 243, Green: 244, Blue: 246}
-	ColorTextMain  = &props.Color{Red: 31, Green: 41, Blue: 55}
-	ColorTextMute  = &props.Color{Red: 107, Green: 114, Blue: 128}
-)
-
-// GetMarotoInstance configures a Maroto PDF with consistent header/footer branding.
-func GetMarotoInstance(title, subtitle string) core.Maroto {
+// GetMarotoInstance configures a Maroto PDF with the report theme's header
+// (logo, organization name, title) and footer (footer text, contact details,
+// generation date). Theme and language are carried by ctx.
+func GetMarotoInstance(ctx context.Context, title, subtitle string) core.Maroto {
+	l, th := localeFrom(ctx), themeFrom(ctx)
 	cfg := config.NewBuilder().
 		WithLeftMargin(15).
 		WithRightMargin(15).
 		WithTopMargin(18).
+		WithDefaultFont(&props.Font{Family: th.Font}).
+		WithCustomFonts(customFontEntities()).
 		Build()
 
 	m := maroto.New(cfg)
 
-	headerRows := []core.Row{
-		text.NewRow(16, title, props.Text{
+	var headerRows []core.Row
+	orgText := props.Text{Style: fontstyle.Bold, Size: 10, Color: th.Secondary, Top: 2}
+	if th.Logo != nil {
+		besideLogo := orgText
+		besideLogo.Top, besideLogo.Left = 4, 3
+		headerRows = append(headerRows, row.New(14).Add(logoCol(th, 2), th.textCol(10, th.OrgName, besideLogo)))
+	} else if th.OrgName != "" {
+		headerRows = append(headerRows, th.textRow(8, th.OrgName, orgText))
+	}
+	headerRows = append(headerRows,
+		th.textRow(16, title, props.Text{
 			Style: fontstyle.Bold,
 			Size:  16,
-			Color: ColorPrimary,
+			Color: th.Primary,
 		}),
-		text.NewRow(10, subtitle, props.Text{
+		th.textRow(10, subtitle, props.Text{
 			Size:  10,
-			Color: ColorTextMute,
+			Color: th.TextMute,
 		}),
 		line.NewRow(4),
-	}
+	)
 	if err := m.RegisterHeader(headerRows...); err != nil {
 		panic(fmt.Sprintf("failed to register header: %v", err))
 	}
 
+	footerText := props.Text{
+		Size:  8,
+		Style: fontstyle.Italic,
+		Color: th.TextMute,
+	}
+	generatedText := footerText
+	generatedText.Align = align.Right
 	footerRows := []core.Row{
 		line.NewRow(4),
-		text.NewRow(8, fmt.Sprintf("Generated %s", time.Now().Format("02 Jan 2006")), props.Text{
-			Size:  8,
-			Style: fontstyle.Italic,
-			Color: ColorTextMute,
-			Align: align.Right,
-		}),
+		row.New(8).Add(
+			th.textCol(8, th.footer(), footerText),
+			th.textCol(4, l.Sprintf("Dibuat %s", l.Date(time.Now())), generatedText),
+		),
 	}
 	if err := m.RegisterFooter(footerRows...); err != nil {
 		panic(fmt.Sprintf("failed to register footer: %v", err))
@@ -70,25 +87,77 @@ func GetMarotoInstance(title, subtitle string) core.Maroto {
 }
 
 // addSectionTitle draws a consistent section heading row across reports.
-func addSectionTitle(m core.Maroto, title string) {
-	row := m.AddRow(10, text.NewCol(12, title, props.Text{
+func addSectionTitle(m core.Maroto, th *Theme, title string) {
+	row := m.AddRow(10, th.textCol(12, title, props.Text{
 		Style: fontstyle.Bold,
 		Size:  12,
-		Color: ColorSecondary,
+		Color: th.Secondary,
 	}))
-	row.WithStyle(&props.Cell{BackgroundColor: ColorBgLight})
+	row.WithStyle(&props.Cell{BackgroundColor: th.BgLight})
 	m.AddRow(4, text.NewCol(12, ""))
 }
 
-func downloadImageAsJPG(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+// imageClient caps each documentation photo download; the job context's
+// deadline still applies on top of it.
+var imageClient = &http.Client{Timeout: 30 * time.Second}
+
+// maxPhotosPerItem is how many documentation photos fit in one row.
+const maxPhotosPerItem = 4
+
+// photoCacheKey holds the *photoCache attached to a job's context.
+type photoCacheKey struct{}
+
+// photoCache keeps converted photos for the duration of one job so that
+// rendering several formats downloads each photo only once.
+type photoCache struct {
+	mu     sync.Mutex
+	images map[string][]byte
+}
+
+func withPhotoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, photoCacheKey{}, &photoCache{images: make(map[string][]byte)})
+}
+
+func downloadImageAsJPG(ctx context.Context, url string) ([]byte, error) {
+	cache, _ := ctx.Value(photoCacheKey{}).(*photoCache)
+	if cache == nil {
+		return fetchImageAsJPG(ctx, url)
+	}
+	cache.mu.Lock()
+	imgBytes, ok := cache.images[url]
+	cache.mu.Unlock()
+	if ok {
+		return imgBytes, nil
+	}
+	imgBytes, err := fetchImageAsJPG(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	cache.mu.Lock()
+	cache.images[url] = imgBytes
+	cache.mu.Unlock()
+	return imgBytes, nil
+}
+
+func fetchImageAsJPG(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := imageClient.Do(req)
 	if err != nil {
+		metrics.ImageDownloadFailures.WithLabelValues("report_photo").Inc()
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		metrics.ImageDownloadFailures.WithLabelValues("report_photo").Inc()
+		return nil, fmt.Errorf("gagal unduh gambar, status: %d", resp.StatusCode)
+	}
 
 	img, _, err := image.Decode(resp.Body)
 	if err != nil {
+		metrics.ImageDownloadFailures.WithLabelValues("report_photo").Inc()
 		return nil, err
 	}
 
@@ -99,6 +168,27 @@ func downloadImageAsJPG(url string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// photoProgress reports "downloading images n/total" while a report fetches
+// its documentation photos, spread over the rendering part of the job.
+type photoProgress struct {
+	done  int
+	total int
+}
+
+func newPhotoProgress(counts ...int) *photoProgress {
+	p := &photoProgress{}
+	for _, n := range counts {
+		p.total += min(n, maxPhotosPerItem)
+	}
+	return p
+}
+
+func (p *photoProgress) downloaded(ctx context.Context) {
+	p.done++
+	progress.Report(ctx, progress.StageDownloadingImages, 30+55*p.done/max(p.total, 1),
+		fmt.Sprintf("downloading images %d/%d", p.done, p.total))
+}
+
 func marotoDocumentBuffer(doc core.Document) *bytes.Buffer {
 	return bytes.NewBuffer(doc.GetBytes())
 }
@@ -3,71 +3,381 @@ package report
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"org-worker/internal/domain"
+	"org-worker/internal/mail"
+	"org-worker/internal/metrics"
+	"org-worker/internal/progress"
 	"org-worker/internal/repository"
+	"org-worker/internal/retry"
 	"org-worker/internal/storage"
+	"org-worker/internal/task"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskType is the task_type producers use to request a report.
+const TaskType = "generate_report"
+
+// Output formats selected by output_format on the reports document.
+const (
+	FormatPDF  = "pdf"
+	FormatXLSX = "xlsx"
+	FormatHTML = "html"
 )
 
+// formatContentTypes lists the MIME type of every supported output format.
+var formatContentTypes = map[string]string{
+	FormatPDF:  "application/pdf",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatHTML: "text/html; charset=utf-8",
+}
+
+// ReportGenerator fetches the data for one report type and renders it. The
+// data is fetched once and can then be rendered and exported several times.
+type ReportGenerator interface {
+	Fetch(ctx context.Context, filters map[string]interface{}) (any, error)
+	Render(ctx context.Context, data any, format string) (*bytes.Buffer, error)
+	// Supports reports whether the generator has a renderer for format.
+	Supports(format string) bool
+}
+
+// Renderer turns the data of one report type into a file of one format.
+type Renderer[T any] func(ctx context.Context, data T) (*bytes.Buffer, error)
+
+type typedGenerator[T any] struct {
+	fetch     func(ctx context.Context, filters map[string]interface{}) (T, error)
+	renderers map[string]Renderer[T]
+}
+
+// NewReportGenerator pairs a data fetch with a renderer per output format.
+func NewReportGenerator[T any](fetch func(ctx context.Context, filters map[string]interface{}) (T, error), renderers map[string]Renderer[T]) ReportGenerator {
+	return &typedGenerator[T]{fetch: fetch, renderers: renderers}
+}
+
+func (g *typedGenerator[T]) Fetch(ctx context.Context, filters map[string]interface{}) (any, error) {
+	return g.fetch(ctx, filters)
+}
+
+func (g *typedGenerator[T]) Render(ctx context.Context, data any, format string) (*bytes.Buffer, error) {
+	render, ok := g.renderers[format]
+	if !ok {
+		return nil, retry.Permanent(fmt.Errorf("format output tidak didukung: %s", format))
+	}
+	return render(ctx, data.(T))
+}
+
+func (g *typedGenerator[T]) Supports(format string) bool {
+	_, ok := g.renderers[format]
+	return ok
+}
+
 type ReportHandler struct {
-	repo    *repository.ReportRepository
-	storage storage.StorageProvider
+	repo       *repository.ReportRepository
+	storage    storage.StorageProvider
+	policy     retry.Policy
+	mailer     *mail.Mailer
+	generators map[string]ReportGenerator
+}
+
+// NewReportHandler builds the handler with the built-in report types. With a
+// nil mailer reports are never emailed.
+func NewReportHandler(repo *repository.ReportRepository, storage storage.StorageProvider, policy retry.Policy, mailer *mail.Mailer) *ReportHandler {
+	h := &ReportHandler{repo: repo, storage: storage, policy: policy, mailer: mailer, generators: make(map[string]ReportGenerator)}
+	h.RegisterReportType("community_activity", NewReportGenerator(repo.GetCommunityActivityData, map[string]Renderer[domain.CommunityActivityData]{
+		FormatPDF:  TemplatePDF[domain.CommunityActivityData],
+		FormatXLSX: GenerateCommunityActivityXLSX,
+		FormatHTML: TemplateHTML[domain.CommunityActivityData],
+	}))
+	h.RegisterReportType("participant_demographics", NewReportGenerator(repo.GetParticipantDemographicsData, map[string]Renderer[domain.ParticipantDemographicsData]{
+		FormatPDF:  TemplatePDF[domain.ParticipantDemographicsData],
+		FormatXLSX: GenerateDemographicsXLSX,
+		FormatHTML: TemplateHTML[domain.ParticipantDemographicsData],
+	}))
+	h.RegisterReportType("program_impact", NewReportGenerator(repo.GetProgramImpactData, map[string]Renderer[domain.ProgramImpactData]{
+		FormatPDF:  TemplatePDF[domain.ProgramImpactData],
+		FormatXLSX: GenerateImpactXLSX,
+		FormatHTML: TemplateHTML[domain.ProgramImpactData],
+	}))
+	h.RegisterReportType("financial_summary", NewReportGenerator(repo.GetFinancialSummaryData, map[string]Renderer[domain.FinancialReportData]{
+		FormatPDF:  TemplatePDF[domain.FinancialReportData],
+		FormatXLSX: GenerateFinancialXLSX,
+		FormatHTML: TemplateHTML[domain.FinancialReportData],
+	}))
+	return h
+}
+
+// RegisterReportType makes a report type available to generate_report jobs.
+func (h *ReportHandler) RegisterReportType(reportType string, generator ReportGenerator) {
+	h.generators[reportType] = generator
+}
+
+// Register adds the generate_report task to the worker's registry.
+func (h *ReportHandler) Register(registry *task.Registry) {
+	registry.Register(TaskType, task.JSONDecoder[domain.ReportJobPayload](), h)
 }
 
-func NewReportHandler(repo *repository.ReportRepository, storage storage.StorageProvider) *ReportHandler {
-	return &ReportHandler{repo: repo, storage: storage}
+func (h *ReportHandler) Handle(ctx context.Context, logger *slog.Logger, payload any) error {
+	p := payload.(domain.ReportJobPayload)
+	logger = logger.With("reportID", p.ReportID)
+	logger.Info("Received job")
+
+	reportDoc, err := h.repo.GetReportByID(ctx, p.ReportID)
+	if err != nil {
+		return fmt.Errorf("failed to get report %s: %w", p.ReportID, err)
+	}
+	return h.HandleReportGeneration(ctx, logger, reportDoc)
 }
 
-func (h *ReportHandler) HandleReportGeneration(ctx context.Context, logger *slog.Logger, reportDoc domain.ReportDoc) error {
-	logger.Info("Mulai memproses laporan")
-	pdfBuffer, err := h.generatePDF(ctx, reportDoc)
+func (h *ReportHandler) DocumentID(payload any) string {
+	return payload.(domain.ReportJobPayload).ReportID
+}
+
+// subtypeLookupTimeout bounds the report lookup Subtype does for payloads
+// without a reportType before the job can take its pool slots.
+const subtypeLookupTimeout = 2 * time.Second
+
+// Subtype puts each report type in its own concurrency pool. The type comes
+// from the payload's reportType or, when a producer left it out, from the
+// report document, so per-type limits hold either way.
+func (h *ReportHandler) Subtype(ctx context.Context, payload any) string {
+	p := payload.(domain.ReportJobPayload)
+	if p.ReportType != "" {
+		return p.ReportType
+	}
+	lookupCtx, cancel := context.WithTimeout(ctx, subtypeLookupTimeout)
+	defer cancel()
+	reportType, err := h.repo.GetReportType(lookupCtx, p.ReportID)
 	if err != nil {
-		logger.Error("Gagal membuat buffer PDF", "err", err)
-		_ = h.repo.UpdateReportStatus(ctx, reportDoc.ID, "failed", "", err.Error())
-		return err
+		slog.Warn("Tipe laporan tidak diketahui, batas per tipe tidak berlaku", "reportID", p.ReportID, "err", err)
+		return ""
 	}
-	filename := fmt.Sprintf("%s-%s.pdf", reportDoc.Type, reportDoc.ID)
-	fileURL, err := h.storage.Save(ctx, reportDoc.Type, filename, pdfBuffer)
+	return reportType
+}
+
+func (h *ReportHandler) Status(ctx context.Context, payload any) (string, error) {
+	id, err := primitive.ObjectIDFromHex(payload.(domain.ReportJobPayload).ReportID)
 	if err != nil {
-		logger.Error("Gagal menyimpan file ke storage", "err", err)
-		_ = h.repo.UpdateReportStatus(ctx, reportDoc.ID, "failed", "", err.Error())
-		return err
+		return "", retry.Permanent(err)
 	}
-	if err := h.repo.UpdateReportStatus(ctx, reportDoc.ID, "completed", fileURL, ""); err != nil {
-		logger.Error("Gagal memperbarui status laporan", "err", err)
+	return h.repo.GetReportStatus(ctx, id)
+}
+
+func (h *ReportHandler) Claim(ctx context.Context, payload any, owner string, leaseUntil time.Time) error {
+	p := payload.(domain.ReportJobPayload)
+	id, err := primitive.ObjectIDFromHex(p.ReportID)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	return h.repo.ClaimReport(ctx, id, owner, leaseUntil, p.Force)
+}
+
+func (h *ReportHandler) Release(ctx context.Context, payload any, owner string) error {
+	id, err := primitive.ObjectIDFromHex(payload.(domain.ReportJobPayload).ReportID)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	return h.repo.ReleaseReport(ctx, id, owner)
+}
+
+func (h *ReportHandler) MarkStatus(ctx context.Context, payload any, status, errMsg string) error {
+	p := payload.(domain.ReportJobPayload)
+	id, err := primitive.ObjectIDFromHex(p.ReportID)
+	if err != nil {
 		return err
 	}
-	logger.Info("Laporan berhasil dibuat dan disimpan", "fileURL", fileURL)
-	return nil
+	return h.repo.UpdateReportStatus(ctx, id, task.Owner(ctx), status, "", errMsg)
 }
 
-func (h *ReportHandler) generatePDF(ctx context.Context, reportDoc domain.ReportDoc) (*bytes.Buffer, error) {
-	switch reportDoc.Type {
-	case "community_activity":
-		data, err := h.repo.GetCommunityActivityData(ctx, reportDoc.Filters)
+func (h *ReportHandler) Result(ctx context.Context, payload any) (domain.JobResult, error) {
+	doc, err := h.repo.GetReportByID(ctx, payload.(domain.ReportJobPayload).ReportID)
+	if err != nil {
+		return domain.JobResult{}, err
+	}
+	return domain.JobResult{
+		Status:      doc.Status,
+		ResultURL:   doc.FileURL,
+		ResultURLs:  doc.FileURLs,
+		ErrorMsg:    doc.ErrorMsg,
+		Attempts:    doc.Attempts,
+		CallbackURL: doc.CallbackURL,
+	}, nil
+}
+
+func (h *ReportHandler) HandleReportGeneration(ctx context.Context, logger *slog.Logger, reportDoc domain.ReportDoc) (err error) {
+	reportType := reportDoc.Type
+	if _, ok := h.generators[reportType]; !ok {
+		reportType = "unknown"
+	}
+	start := time.Now()
+	defer func() {
+		outcome := "completed"
 		if err != nil {
-			return nil, err
+			outcome = "failed"
 		}
-		return GenerateCommunityActivityPDF(data)
-	case "participant_demographics":
-		data, err := h.repo.GetParticipantDemographicsData(ctx, reportDoc.Filters)
+		metrics.ReportsTotal.WithLabelValues(reportType, outcome).Inc()
+		metrics.ReportDuration.WithLabelValues(reportType).Observe(time.Since(start).Seconds())
+	}()
+
+	attempt := reportDoc.Attempts + 1
+	if n, err := h.repo.IncrementAttempts(ctx, reportDoc.ID); err == nil {
+		attempt = n
+	}
+	logger.Info("Mulai memproses laporan", "attempt", attempt)
+
+	ctx = progress.WithReporter(ctx, progress.NewReporter(func(ctx context.Context, p domain.Progress) error {
+		return h.repo.UpdateReportProgress(ctx, reportDoc.ID, p)
+	}))
+	progress.Report(ctx, progress.StageFetchingData, 5, "fetching data")
+
+	formats := reportFormats(reportDoc)
+	generator, err := h.generator(reportDoc, formats)
+	if err != nil {
+		return h.fail(ctx, reportDoc.ID, attempt, err)
+	}
+	locale, err := reportLocale(reportDoc.Filters)
+	if err != nil {
+		return h.fail(ctx, reportDoc.ID, attempt, err)
+	}
+	ctx = withLocale(ctx, locale)
+	theme, err := h.theme(ctx, logger, reportDoc.Filters)
+	if err != nil {
+		logger.Error("Gagal memuat tema laporan", "err", err)
+		return h.fail(ctx, reportDoc.ID, attempt, err)
+	}
+	ctx = withTheme(ctx, theme)
+	tpl, err := h.template(ctx, reportDoc)
+	if err != nil {
+		logger.Error("Gagal memuat template laporan", "err", err)
+		return h.fail(ctx, reportDoc.ID, attempt, err)
+	}
+	ctx = withTemplate(ctx, tpl)
+	data, err := generator.Fetch(ctx, reportDoc.Filters)
+	if err != nil {
+		logger.Error("Gagal mengambil data laporan", "err", err)
+		return h.fail(ctx, reportDoc.ID, attempt, err)
+	}
+
+	// Every format is rendered from the same data; photos downloaded for
+	// one are reused by the next.
+	ctx = withPhotoCache(ctx)
+	files := make([]reportFile, 0, len(formats))
+	fileURLs := make(map[string]string, len(formats))
+	for i, format := range formats {
+		progress.Report(ctx, progress.StageRendering, 30+60*i/len(formats), "rendering "+format)
+		fileBuffer, err := generator.Render(ctx, data, format)
 		if err != nil {
-			return nil, err
+			logger.Error("Gagal membuat file laporan", "format", format, "err", err)
+			return h.fail(ctx, reportDoc.ID, attempt, err)
+		}
+		file := reportFile{
+			Filename:    fmt.Sprintf("%s-%s.%s", reportDoc.Type, reportDoc.ID, format),
+			ContentType: formatContentTypes[format],
+			Content:     fileBuffer.Bytes(),
 		}
-		return GenerateDemographicsPDF(data)
-	case "program_impact":
-		data, err := h.repo.GetProgramImpactData(ctx, reportDoc.Filters)
+		file.URL, err = h.storage.Save(ctx, reportDoc.Type, file.Filename, fileBuffer)
 		if err != nil {
-			return nil, err
+			logger.Error("Gagal menyimpan file ke storage", "format", format, "err", err)
+			return h.fail(ctx, reportDoc.ID, attempt, err)
 		}
-		return GenerateImpactPDF(data)
-	case "financial_summary":
-		data, err := h.repo.GetFinancialSummaryData(ctx, reportDoc.Filters)
+		files = append(files, file)
+		fileURLs[format] = file.URL
+	}
+	progress.Report(ctx, progress.StageUploading, 90, "uploading")
+	// fileURL keeps pointing at the first format for older clients.
+	fileURL := files[0].URL
+	if len(reportDoc.Exports) > 0 {
+		exportURLs, err := h.saveExports(ctx, reportDoc, data)
 		if err != nil {
-			return nil, err
+			logger.Error("Gagal menyimpan ekspor data mentah", "err", err)
+			return h.fail(ctx, reportDoc.ID, attempt, err)
+		}
+		if err := h.repo.UpdateReportExports(ctx, reportDoc.ID, exportURLs); err != nil {
+			logger.Error("Gagal mencatat URL ekspor", "err", err)
+			return h.fail(ctx, reportDoc.ID, attempt, err)
+		}
+	}
+	if len(reportDoc.Recipients) > 0 {
+		progress.Report(ctx, progress.StageDelivering, 95, "delivering")
+		h.deliver(ctx, logger, reportDoc, files)
+	}
+	if err := h.repo.CompleteReport(ctx, reportDoc.ID, task.Owner(ctx), fileURL, fileURLs); err != nil {
+		if errors.Is(err, domain.ErrLeaseLost) {
+			return err
+		}
+		logger.Error("Gagal memperbarui status laporan", "err", err)
+		return h.fail(ctx, reportDoc.ID, attempt, err)
+	}
+	progress.Report(ctx, progress.StageCompleted, 100, "completed")
+	logger.Info("Laporan berhasil dibuat dan disimpan", "fileURL", fileURL)
+	return nil
+}
+
+// fail records the error on the report and, when the policy allows another
+// attempt, returns a retry.ScheduledError so the caller re-enqueues the job.
+// A report whose claim was lost is left to its new owner.
+func (h *ReportHandler) fail(ctx context.Context, id primitive.ObjectID, attempt int, err error) error {
+	owner := task.Owner(ctx)
+	if h.policy.CanRetry(attempt, err) {
+		delay := h.policy.Backoff(attempt)
+		if werr := h.repo.MarkReportRetrying(ctx, id, owner, err.Error(), time.Now().Add(delay)); errors.Is(werr, domain.ErrLeaseLost) {
+			return werr
+		}
+		return &retry.ScheduledError{Err: err, Attempt: attempt, Delay: delay}
+	}
+	if werr := h.repo.UpdateReportStatus(ctx, id, owner, "failed", "", err.Error()); errors.Is(werr, domain.ErrLeaseLost) {
+		return werr
+	}
+	return &retry.ExhaustedError{Err: err, Attempt: attempt}
+}
+
+// reportFile is one rendered output format of a report.
+type reportFile struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+	URL         string
+}
+
+// reportFormats returns the requested output formats without duplicates:
+// formats if set, otherwise output_format, otherwise PDF.
+func reportFormats(reportDoc domain.ReportDoc) []string {
+	requested := reportDoc.Formats
+	if len(requested) == 0 && reportDoc.OutputFormat != "" {
+		requested = []string{reportDoc.OutputFormat}
+	}
+	if len(requested) == 0 {
+		return []string{FormatPDF}
+	}
+	formats := make([]string, 0, len(requested))
+	for _, format := range requested {
+		if !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// generator checks that the report type, output formats and exports are all
+// known before any data is fetched.
+func (h *ReportHandler) generator(reportDoc domain.ReportDoc, formats []string) (ReportGenerator, error) {
+	generator, ok := h.generators[reportDoc.Type]
+	if !ok {
+		return nil, retry.Permanent(fmt.Errorf("tipe laporan tidak dikenal: %s", reportDoc.Type))
+	}
+	for _, format := range formats {
+		if !generator.Supports(format) {
+			return nil, retry.Permanent(fmt.Errorf("format output tidak didukung: %s", format))
+		}
+	}
+	for _, export := range reportDoc.Exports {
+		if _, ok := exporters[export]; !ok {
+			return nil, retry.Permanent(fmt.Errorf("format ekspor tidak didukung: %s", export))
 		}
-		return GenerateFinancialPDF(data)
 	}
-	return nil, fmt.Errorf("tipe laporan tidak dikenal: %s", reportDoc.Type)
+	return generator, nil
 }
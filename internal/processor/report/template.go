@@ -0,0 +1,426 @@
+package report
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"org-worker/internal/domain"
+	"org-worker/internal/retry"
+)
+
+//go:embed templates/builtin/*.json
+var builtinTemplateFS embed.FS
+
+// builtinTemplates holds the default template of every report type, keyed
+// by template name (the report type).
+var builtinTemplates = func() map[string]*domain.ReportTemplate {
+	paths, err := fs.Glob(builtinTemplateFS, "templates/builtin/*.json")
+	if err != nil {
+		panic(err)
+	}
+	templates := make(map[string]*domain.ReportTemplate, len(paths))
+	for _, path := range paths {
+		raw, err := builtinTemplateFS.ReadFile(path)
+		if err != nil {
+			panic(err)
+		}
+		var tpl domain.ReportTemplate
+		if err := json.Unmarshal(raw, &tpl); err != nil {
+			panic(fmt.Sprintf("invalid report template %s: %v", path, err))
+		}
+		if err := validateTemplate(&tpl); err != nil {
+			panic(fmt.Sprintf("invalid report template %s: %v", path, err))
+		}
+		templates[tpl.Name] = &tpl
+	}
+	return templates
+}()
+
+// template picks the template for a report: the template field if set,
+// otherwise the report type. A report_templates document takes precedence
+// over the built-in template of the same name.
+func (h *ReportHandler) template(ctx context.Context, reportDoc domain.ReportDoc) (*domain.ReportTemplate, error) {
+	name := reportDoc.Template
+	if name == "" {
+		name = reportDoc.Type
+	}
+	tpl, err := h.repo.GetReportTemplate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if tpl == nil {
+		tpl = builtinTemplates[name]
+	}
+	if tpl == nil {
+		return nil, retry.Permanent(fmt.Errorf("template laporan tidak dikenal: %s", name))
+	}
+	if tpl.ReportType != reportDoc.Type {
+		return nil, retry.Permanent(fmt.Errorf("template %s untuk tipe laporan %s, bukan %s", name, tpl.ReportType, reportDoc.Type))
+	}
+	if err := validateTemplate(tpl); err != nil {
+		return nil, retry.Permanent(fmt.Errorf("template %s tidak valid: %w", name, err))
+	}
+	return tpl, nil
+}
+
+var (
+	chartKinds   = []string{"pie", "bar", "line"}
+	columnAligns = []string{"", "left", "center", "right"}
+)
+
+// validateTemplate checks what can be checked without data. Field paths are
+// resolved while rendering.
+func validateTemplate(tpl *domain.ReportTemplate) error {
+	for _, section := range tpl.Sections {
+		for _, chart := range section.Charts {
+			if !slices.Contains(chartKinds, chart.Kind) {
+				return fmt.Errorf("jenis grafik tidak dikenal: %q", chart.Kind)
+			}
+			if chart.Source == "" {
+				return fmt.Errorf("grafik di bagian %q tanpa source", section.Title)
+			}
+		}
+		if section.Table != nil {
+			if len(section.Table.Columns) > 12 {
+				return fmt.Errorf("tabel di bagian %q memiliki lebih dari 12 kolom", section.Title)
+			}
+			width := 0
+			for _, column := range section.Table.Columns {
+				if !slices.Contains(columnAligns, column.Align) {
+					return fmt.Errorf("align kolom tidak dikenal: %q", column.Align)
+				}
+				if column.Width < 0 {
+					return fmt.Errorf("lebar kolom tabel di bagian %q negatif", section.Title)
+				}
+				// Columns without a width take at least 1.
+				width += max(column.Width, 1)
+			}
+			if width > 12 {
+				return fmt.Errorf("lebar kolom tabel di bagian %q melebihi 12", section.Title)
+			}
+		}
+		if section.Items != nil && section.Items.Source == "" {
+			return fmt.Errorf("items di bagian %q tanpa source", section.Title)
+		}
+	}
+	return nil
+}
+
+type templateKey struct{}
+
+// withTemplate attaches the report's template to the job context.
+func withTemplate(ctx context.Context, tpl *domain.ReportTemplate) context.Context {
+	return context.WithValue(ctx, templateKey{}, tpl)
+}
+
+// templateFrom returns the template attached to ctx, or nil.
+func templateFrom(ctx context.Context) *domain.ReportTemplate {
+	tpl, _ := ctx.Value(templateKey{}).(*domain.ReportTemplate)
+	return tpl
+}
+
+// scope is where template paths are looked up: the current list item, if
+// any, and then the report data.
+type scope struct {
+	l     *localizer
+	root  reflect.Value
+	item  reflect.Value
+	index int
+}
+
+func newScope(l *localizer, data any) scope {
+	return scope{l: l, root: reflect.ValueOf(data)}
+}
+
+func (s scope) at(item reflect.Value, index int) scope {
+	s.item, s.index = item, index
+	return s
+}
+
+// value resolves a path such as "stats.level_up.count".
+func (s scope) value(path string) (reflect.Value, error) {
+	if s.item.IsValid() {
+		if v, ok := walkPath(s.item, path); ok {
+			return v, nil
+		}
+	}
+	if v, ok := walkPath(s.root, path); ok {
+		return v, nil
+	}
+	return reflect.Value{}, fmt.Errorf("field template tidak dikenal: %s", path)
+}
+
+// list resolves a path that must point at a slice.
+func (s scope) list(path string) (reflect.Value, error) {
+	v, err := s.value(path)
+	if err != nil {
+		return v, err
+	}
+	if v.Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf("field template %s bukan daftar", path)
+	}
+	return v, nil
+}
+
+// empty reports whether the value at path is zero, nil or an empty list.
+func (s scope) empty(path string) (bool, error) {
+	v, err := s.value(path)
+	if err != nil {
+		return false, err
+	}
+	if v.Kind() == reflect.Slice {
+		return v.Len() == 0, nil
+	}
+	if isNumber(v) {
+		return toFloat(v) <= 0, nil
+	}
+	return v.IsZero(), nil
+}
+
+// present reports whether path resolves without passing a nil pointer, e.g.
+// whether the report has a comparison period.
+func (s scope) present(path string) bool {
+	keys := strings.Split(path, ".")
+	for i := range keys {
+		v, err := s.value(strings.Join(keys[:i+1], "."))
+		if err != nil || (v.Kind() == reflect.Pointer && v.IsNil()) {
+			return false
+		}
+	}
+	return true
+}
+
+// walkPath follows dotted JSON field names. A segment applied to a list is
+// an index or, for lists of stats, the id of an entry; a missing entry or a
+// nil pointer yields the zero value so absent stats read as 0.
+func walkPath(v reflect.Value, path string) (reflect.Value, bool) {
+	for _, key := range strings.Split(path, ".") {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v = reflect.Zero(v.Type().Elem())
+			} else {
+				v = v.Elem()
+			}
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByJSONName(v, key)
+			if !ok {
+				return reflect.Value{}, false
+			}
+			v = field
+		case reflect.Slice:
+			v = sliceEntry(v, key)
+		default:
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
+
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.IsExported() && jsonName(field) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func sliceEntry(v reflect.Value, key string) reflect.Value {
+	if i, err := strconv.Atoi(key); err == nil {
+		if i >= 0 && i < v.Len() {
+			return v.Index(i)
+		}
+		return reflect.Zero(v.Type().Elem())
+	}
+	for i := 0; i < v.Len(); i++ {
+		entry := reflect.Indirect(v.Index(i))
+		if entry.Kind() != reflect.Struct {
+			break
+		}
+		if id, ok := fieldByJSONName(entry, "id"); ok && fmt.Sprint(id.Interface()) == key {
+			return entry
+		}
+	}
+	return reflect.Zero(v.Type().Elem())
+}
+
+// arg evaluates one argument expression: a path or "#", then filters.
+func (s scope) arg(expr string) (any, error) {
+	parts := strings.Split(expr, "|")
+	var v reflect.Value
+	if path := strings.TrimSpace(parts[0]); path == "#" {
+		v = reflect.ValueOf(s.index + 1)
+	} else {
+		var err error
+		if v, err = s.value(path); err != nil {
+			return nil, err
+		}
+	}
+	for _, filter := range parts[1:] {
+		name, param, _ := strings.Cut(strings.TrimSpace(filter), ":")
+		var err error
+		if v, err = s.filter(v, name, param); err != nil {
+			return nil, fmt.Errorf("%s: %w", expr, err)
+		}
+	}
+	return v.Interface(), nil
+}
+
+func (s scope) filter(v reflect.Value, name, param string) (reflect.Value, error) {
+	switch name {
+	case "date":
+		t, ok := v.Interface().(time.Time)
+		if !ok {
+			return v, fmt.Errorf("filter date butuh tanggal")
+		}
+		return reflect.ValueOf(s.l.Date(t)), nil
+	case "period":
+		t, ok := v.Interface().(time.Time)
+		if !ok {
+			return v, fmt.Errorf("filter period butuh tanggal")
+		}
+		interval, err := s.value(param)
+		if err != nil {
+			return v, err
+		}
+		// Monthly trend buckets are named by month, weekly ones by their
+		// first day.
+		if interval.Kind() == reflect.String && interval.String() == "month" {
+			return reflect.ValueOf(s.l.Month(t)), nil
+		}
+		return reflect.ValueOf(s.l.Day(t)), nil
+	case "money":
+		if !isNumber(v) {
+			return v, fmt.Errorf("filter money butuh angka")
+		}
+		return reflect.ValueOf(s.l.Money(toFloat(v))), nil
+	case "count":
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Map && v.Kind() != reflect.String {
+			return v, fmt.Errorf("filter count butuh daftar")
+		}
+		return reflect.ValueOf(v.Len()), nil
+	case "percent":
+		total, err := s.value(param)
+		if err != nil {
+			return v, err
+		}
+		if !isNumber(v) || !isNumber(total) {
+			return v, fmt.Errorf("filter percent butuh angka")
+		}
+		percentage := 0.0
+		if t := toFloat(total); t != 0 {
+			percentage = toFloat(v) / t * 100
+		}
+		return reflect.ValueOf(percentage), nil
+	case "default":
+		if v.IsZero() {
+			return reflect.ValueOf(s.l.Sprintf(param)), nil
+		}
+		return v, nil
+	case "milestone":
+		return reflect.ValueOf(milestoneLabel(s.l, fmt.Sprint(v.Interface()))), nil
+	}
+	return v, fmt.Errorf("filter template tidak dikenal: %s", name)
+}
+
+// text translates t.Format and fills in its arguments.
+func (s scope) text(t domain.TemplateText) (string, error) {
+	if t.Format == "" {
+		return "", nil
+	}
+	args := make([]any, 0, len(t.Args))
+	for _, expr := range t.Args {
+		arg, err := s.arg(expr)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, arg)
+	}
+	return s.l.Sprintf(t.Format, args...), nil
+}
+
+// change describes how the metric moved since the comparison period: the
+// signed difference, the percentage change when there is a base to take it
+// of, and whether the direction is the good one.
+func (s scope) change(tc *domain.TemplateChange) (text string, trend int, good bool, err error) {
+	current, err := s.number(tc.Current)
+	if err != nil {
+		return "", 0, false, err
+	}
+	previous, err := s.number(tc.Previous)
+	if err != nil {
+		return "", 0, false, err
+	}
+	diff := current - previous
+	if diff == 0 {
+		return s.l.Sprintf("Tidak berubah"), 0, true, nil
+	}
+	trend = 1
+	if diff < 0 {
+		trend = -1
+	}
+	good = (trend > 0) != tc.LowerIsBetter
+
+	var amount string
+	switch {
+	case tc.Money && diff > 0:
+		amount = "+" + s.l.Money(diff)
+	case tc.Money:
+		amount = "-" + s.l.Money(-diff)
+	case diff == math.Trunc(diff):
+		amount = s.l.Sprintf("%+d", int64(diff))
+	default:
+		amount = s.l.Sprintf("%+.1f", diff)
+	}
+	if previous == 0 {
+		return amount, trend, good, nil
+	}
+	return s.l.Sprintf("%s (%+.1f%%)", amount, diff/math.Abs(previous)*100), trend, good, nil
+}
+
+// number evaluates expr and converts it to a float.
+func (s scope) number(expr string) (float64, error) {
+	arg, err := s.arg(expr)
+	if err != nil {
+		return 0, err
+	}
+	v := reflect.ValueOf(arg)
+	if !isNumber(v) {
+		return 0, fmt.Errorf("field template %s bukan angka", expr)
+	}
+	return toFloat(v), nil
+}
+
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return 0
+}
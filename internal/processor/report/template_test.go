@@ -0,0 +1,227 @@
+package report
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"org-worker/internal/domain"
+
+	"golang.org/x/text/language"
+)
+
+func testImpactData() domain.ProgramImpactData {
+	return domain.ProgramImpactData{
+		CommunityName: "Komunitas A",
+		StartDate:     time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+		Stats:         []domain.MilestoneStat{{ID: "level_up", Count: 4}, {ID: "certified", Count: 0}},
+		Highlights:    []domain.ImpactHighlight{{Title: "Lulus", OwnerName: "Sari"}},
+	}
+}
+
+func TestScopeArg(t *testing.T) {
+	data := testImpactData()
+	data.Comparison = &domain.ImpactComparison{Stats: []domain.MilestoneStat{{ID: "level_up", Count: 5}}}
+	trend := domain.CommunityActivityData{TrendInterval: "month", Trend: []domain.ActivityBucket{{Start: data.StartDate}}}
+	weekly := domain.CommunityActivityData{TrendInterval: "week", Trend: []domain.ActivityBucket{{Start: time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC)}}}
+	tests := []struct {
+		name    string
+		data    any
+		expr    string
+		want    any
+		wantErr string
+	}{
+		{"field", data, "communityName", "Komunitas A", ""},
+		{"date filter", data, "startDate|date", "01 Mei 2025", ""},
+		{"entry by id", data, "stats.level_up.count", 4, ""},
+		{"entry by index", data, "stats.1.id", "certified", ""},
+		{"missing entry reads as zero", data, "stats.other.count", 0, ""},
+		{"through a pointer", data, "comparison.stats.level_up.count", 5, ""},
+		{"count filter", data, "stats|count", 2, ""},
+		{"default filter on empty", data, "highlights.0.summary|default:Tidak ada", "Tidak ada", ""},
+		{"default filter on value", data, "highlights.0.ownerName|default:Tidak ada", "Sari", ""},
+		{"money filter", domain.FinancialReportData{NetIncome: 1500}, "netIncome|money", "Rp 1.500", ""},
+		{"percent filter", data, "stats.level_up.count|percent:stats.level_up.count", 100.0, ""},
+		{"period filter by month", trend, "trend.0.start|period:trendInterval", "Mei 2025", ""},
+		{"period filter by week", weekly, "trend.0.start|period:trendInterval", "12 Mei", ""},
+		{"chained filters", data, "stats|count|default:x", 2, ""},
+		{"unknown field", data, "nope", nil, "field template tidak dikenal"},
+		{"unknown filter", data, "communityName|upper", nil, "filter template tidak dikenal"},
+		{"date filter on text", data, "communityName|date", nil, "butuh tanggal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newScope(newLocalizer(language.Indonesian), tt.data).arg(tt.expr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("arg(%q): err %v, want %q", tt.expr, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("arg(%q): %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("arg(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopeListItems(t *testing.T) {
+	s := newScope(newLocalizer(language.English), testImpactData())
+	var lines []string
+	err := eachItem(s, "stats", func(item scope) error {
+		line, err := item.text(domain.TemplateText{Format: "%d. %s %s: %d", Args: []string{"#", "communityName", "id", "count"}})
+		lines = append(lines, line)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1. Komunitas A level_up: 4", "2. Komunitas A certified: 0"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestScopeEmpty(t *testing.T) {
+	s := newScope(newLocalizer(language.Indonesian), testImpactData())
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"stats", false},
+		{"highlights.0.summary", true},
+		{"stats.certified.count", true},
+		{"stats.level_up.count", false},
+		{"comparison", true},
+	}
+	for _, tt := range tests {
+		if got, err := s.empty(tt.path); err != nil || got != tt.want {
+			t.Errorf("empty(%q) = %v, %v; want %v", tt.path, got, err, tt.want)
+		}
+	}
+}
+
+func TestScopePresent(t *testing.T) {
+	s := newScope(newLocalizer(language.Indonesian), testImpactData())
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"stats", true},
+		{"highlights.0.summary", true},
+		{"comparison", false},
+		{"comparison.stats.level_up.count", false},
+	}
+	for _, tt := range tests {
+		if got := s.present(tt.path); got != tt.want {
+			t.Errorf("present(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestScopeChange(t *testing.T) {
+	data := domain.FinancialReportData{
+		TotalIncome:   1250000,
+		TotalExpenses: 300,
+		NetIncome:     50,
+		Comparison: &domain.FinancialComparison{
+			TotalIncome:   1000000,
+			TotalExpenses: 400,
+			NetIncome:     50,
+		},
+	}
+	tests := []struct {
+		name      string
+		change    domain.TemplateChange
+		want      string
+		wantTrend int
+		wantGood  bool
+	}{
+		{"money increase", domain.TemplateChange{Current: "totalIncome", Previous: "comparison.totalIncome", Money: true}, "+Rp 250.000 (+25,0%)", 1, true},
+		{"decrease where lower is better", domain.TemplateChange{Current: "totalExpenses", Previous: "comparison.totalExpenses", LowerIsBetter: true}, "-100 (-25,0%)", -1, true},
+		{"decrease", domain.TemplateChange{Current: "totalExpenses", Previous: "comparison.totalExpenses"}, "-100 (-25,0%)", -1, false},
+		{"no change", domain.TemplateChange{Current: "netIncome", Previous: "comparison.netIncome"}, "Tidak berubah", 0, true},
+		{"no base for a percentage", domain.TemplateChange{Current: "netIncome", Previous: "comparison.totalInKindValue"}, "+50", 1, true},
+	}
+	s := newScope(newLocalizer(language.Indonesian), data)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, trend, good, err := s.change(&tt.change)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if text != tt.want || trend != tt.wantTrend || good != tt.wantGood {
+				t.Errorf("change = %q, %d, %v; want %q, %d, %v", text, trend, good, tt.want, tt.wantTrend, tt.wantGood)
+			}
+		})
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	column := func(width int, align string) domain.TemplateColumn {
+		return domain.TemplateColumn{Header: "h", Width: width, Align: align}
+	}
+	tests := []struct {
+		name    string
+		section domain.TemplateSection
+		wantErr bool
+	}{
+		{"charts", domain.TemplateSection{Charts: []domain.TemplateChart{{Kind: "pie", Source: "s"}, {Kind: "bar", Source: "s"}, {Kind: "line", Source: "s"}}}, false},
+		{"unknown chart kind", domain.TemplateSection{Charts: []domain.TemplateChart{{Kind: "radar", Source: "s"}}}, true},
+		{"chart without source", domain.TemplateSection{Charts: []domain.TemplateChart{{Kind: "pie"}}}, true},
+		{"table", domain.TemplateSection{Table: &domain.TemplateTable{Source: "s", Columns: []domain.TemplateColumn{column(8, ""), column(4, "right")}}}, false},
+		{"unknown align", domain.TemplateSection{Table: &domain.TemplateTable{Source: "s", Columns: []domain.TemplateColumn{column(4, "justify")}}}, true},
+		{"columns wider than the page", domain.TemplateSection{Table: &domain.TemplateTable{Source: "s", Columns: []domain.TemplateColumn{column(8, ""), column(5, "")}}}, true},
+		{"no room left for unsized columns", domain.TemplateSection{Table: &domain.TemplateTable{Source: "s", Columns: []domain.TemplateColumn{column(12, ""), column(0, "")}}}, true},
+		{"negative width", domain.TemplateSection{Table: &domain.TemplateTable{Source: "s", Columns: []domain.TemplateColumn{column(-1, "")}}}, true},
+		{"twelve unsized columns", domain.TemplateSection{Table: &domain.TemplateTable{Source: "s", Columns: slices.Repeat([]domain.TemplateColumn{column(0, "")}, 12)}}, false},
+		{"more than twelve columns", domain.TemplateSection{Table: &domain.TemplateTable{Source: "s", Columns: slices.Repeat([]domain.TemplateColumn{column(0, "")}, 13)}}, true},
+		{"items without source", domain.TemplateSection{Items: &domain.TemplateItems{}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTemplate(&domain.ReportTemplate{Sections: []domain.TemplateSection{tt.section}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateTemplate: err %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestColumnWidths(t *testing.T) {
+	tests := []struct {
+		name   string
+		widths []int
+		want   []int
+	}{
+		{"all explicit", []int{8, 4}, []int{8, 4}},
+		{"all unsized", []int{0, 0, 0}, []int{4, 4, 4}},
+		{"remainder after explicit", []int{6, 0, 0}, []int{6, 3, 3}},
+		{"leftover to the first unsized", []int{0, 0, 0, 0, 0}, []int{3, 3, 2, 2, 2}},
+		{"unsized next to a full row", []int{2, 0, 8, 0}, []int{2, 1, 8, 1}},
+		{"twelve unsized", slices.Repeat([]int{0}, 12), slices.Repeat([]int{1}, 12)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns := make([]domain.TemplateColumn, len(tt.widths))
+			for i, w := range tt.widths {
+				columns[i].Width = w
+			}
+			if got := columnWidths(columns); !slices.Equal(got, tt.want) {
+				t.Errorf("columnWidths(%v) = %v, want %v", tt.widths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltinTemplatesAreValid(t *testing.T) {
+	for name, tpl := range builtinTemplates {
+		if err := validateTemplate(tpl); err != nil {
+			t.Errorf("built-in template %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"org-worker/internal/config"
+	"org-worker/internal/domain"
+	"org-worker/internal/retry"
+
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontfamily"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+)
+
+// Theme is the resolved branding every renderer draws with.
+type Theme struct {
+	OrgName string
+	// Logo is a PNG, or nil when no logo is configured or it failed to load.
+	Logo       []byte
+	Primary    *props.Color
+	Secondary  *props.Color
+	BgLight    *props.Color
+	TextMain   *props.Color
+	TextMute   *props.Color
+	Font       string
+	FooterText string
+	Contact    string
+}
+
+// DefaultTheme is used for whatever the branding leaves unset.
+var DefaultTheme = Theme{
+	Primary:   &props.Color{Red: 30, Green: 58, Blue: 138},
+	Secondary: &props.Color{Red: 59, Green: 130, Blue: 246},
+	BgLight:   &props.Color{Red: 243, Green: 244, Blue: 246},
+	TextMain:  &props.Color{Red: 31, Green: 41, Blue: 55},
+	TextMute:  &props.Color{Red: 107, Green: 114, Blue: 128},
+	Font:      fontfamily.Arial,
+}
+
+// pdfFonts are the font families Maroto ships with; families loaded with
+// LoadFonts can be used as well.
+var pdfFonts = []string{fontfamily.Arial, fontfamily.Helvetica, fontfamily.Courier}
+
+// footer is the left-hand footer line: the footer text (or the organization
+// name) followed by the contact details.
+func (t *Theme) footer() string {
+	parts := make([]string, 0, 2)
+	if t.FooterText != "" {
+		parts = append(parts, t.FooterText)
+	} else if t.OrgName != "" {
+		parts = append(parts, t.OrgName)
+	}
+	if t.Contact != "" {
+		parts = append(parts, t.Contact)
+	}
+	return strings.Join(parts, " | ")
+}
+
+// newTheme applies b on top of DefaultTheme. Colors and the font are
+// checked here; the logo is loaded separately because a missing logo should
+// not fail the report.
+func newTheme(b domain.Branding) (*Theme, error) {
+	theme := DefaultTheme
+	theme.OrgName = b.OrgName
+	theme.FooterText = b.FooterText
+	theme.Contact = b.Contact
+	colors := []struct {
+		value string
+		dst   **props.Color
+	}{
+		{b.Colors.Primary, &theme.Primary},
+		{b.Colors.Secondary, &theme.Secondary},
+		{b.Colors.Background, &theme.BgLight},
+		{b.Colors.Text, &theme.TextMain},
+		{b.Colors.Muted, &theme.TextMute},
+	}
+	for _, c := range colors {
+		if c.value == "" {
+			continue
+		}
+		parsed, err := parseHexColor(c.value)
+		if err != nil {
+			return nil, retry.Permanent(err)
+		}
+		*c.dst = parsed
+	}
+	if b.Font != "" {
+		font := strings.ToLower(b.Font)
+		if !knownFont(font) {
+			return nil, retry.Permanent(fmt.Errorf("font tema tidak didukung: %s", b.Font))
+		}
+		theme.Font = font
+	}
+	return &theme, nil
+}
+
+// parseHexColor reads "#RRGGBB" or "RRGGBB".
+func parseHexColor(s string) (*props.Color, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("warna tema tidak valid: %s", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("warna tema tidak valid: %s", s)
+	}
+	return &props.Color{Red: int(v >> 16 & 0xFF), Green: int(v >> 8 & 0xFF), Blue: int(v & 0xFF)}, nil
+}
+
+// loadLogo reads the logo from an http(s) URL or a local file and re-encodes
+// it as PNG, which keeps transparency and is understood by every format.
+func loadLogo(ctx context.Context, src string) ([]byte, error) {
+	var r io.ReadCloser
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := imageClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("gagal unduh logo, status: %d", resp.StatusCode)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, err
+		}
+		r = f
+	}
+	defer r.Close()
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// theme resolves the branding for a report: the environment defaults, then
+// the organization's branding document, then the community's override.
+func (h *ReportHandler) theme(ctx context.Context, logger *slog.Logger, filters map[string]interface{}) (*Theme, error) {
+	branding := config.GetBranding()
+	community, _ := filters["community_name"].(string)
+	docs, err := h.repo.GetBranding(ctx, community)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		branding = branding.Merge(doc)
+	}
+	theme, err := newTheme(branding)
+	if err != nil {
+		return nil, err
+	}
+	if branding.Logo != "" {
+		if theme.Logo, err = loadLogo(ctx, branding.Logo); err != nil {
+			logger.Warn("Gagal memuat logo, laporan dibuat tanpa logo", "logo", branding.Logo, "err", err)
+		}
+	}
+	return theme, nil
+}
+
+type themeKey struct{}
+
+// withTheme attaches the report's theme to the job context.
+func withTheme(ctx context.Context, theme *Theme) context.Context {
+	return context.WithValue(ctx, themeKey{}, theme)
+}
+
+func themeFrom(ctx context.Context) *Theme {
+	if t, ok := ctx.Value(themeKey{}).(*Theme); ok {
+		return t
+	}
+	return &DefaultTheme
+}
@@ -0,0 +1,368 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"org-worker/internal/domain"
+
+	"github.com/johnfercher/maroto/v2/pkg/props"
+	"github.com/xuri/excelize/v2"
+)
+
+// workbook wraps an excelize file with the styles shared by every report
+// sheet. Values are written as numbers and dates, not preformatted text, so
+// they can be summed and filtered in the spreadsheet.
+type workbook struct {
+	f       *excelize.File
+	sheets  int
+	title   int
+	header  int
+	money   int
+	date    int
+	percent int
+}
+
+func newWorkbook(th *Theme) (*workbook, error) {
+	w := &workbook{f: excelize.NewFile()}
+	styles := []struct {
+		id    *int
+		style *excelize.Style
+	}{
+		{&w.title, &excelize.Style{Font: &excelize.Font{Bold: true, Size: 14, Color: hexColor(th.Primary)}}},
+		{&w.header, &excelize.Style{
+			Font: &excelize.Font{Bold: true, Color: "FFFFFF"},
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{hexColor(th.Primary)}},
+		}},
+		{&w.money, &excelize.Style{CustomNumFmt: stringPtr(`"Rp" #,##0`)}},
+		{&w.date, &excelize.Style{CustomNumFmt: stringPtr("dd mmm yyyy")}},
+		{&w.percent, &excelize.Style{NumFmt: 10}},
+	}
+	for _, s := range styles {
+		id, err := w.f.NewStyle(s.style)
+		if err != nil {
+			return nil, err
+		}
+		*s.id = id
+	}
+	return w, nil
+}
+
+// xlsxColumn describes one column of a table sheet; Style is one of the
+// workbook's style IDs or 0 for plain values.
+type xlsxColumn struct {
+	Header string
+	Width  float64
+	Style  int
+}
+
+// addTable writes a sheet with a title row, a header row and the rows below.
+// The first call renames the default sheet instead of adding one.
+func (w *workbook) addTable(name, title string, columns []xlsxColumn, rows [][]any) error {
+	if w.sheets == 0 {
+		if err := w.f.SetSheetName("Sheet1", name); err != nil {
+			return err
+		}
+	} else if _, err := w.f.NewSheet(name); err != nil {
+		return err
+	}
+	w.sheets++
+
+	w.f.SetCellValue(name, "A1", title)
+	w.f.SetCellStyle(name, "A1", "A1", w.title)
+	for i, col := range columns {
+		letter, _ := excelize.ColumnNumberToName(i + 1)
+		w.f.SetColWidth(name, letter, letter, col.Width)
+		w.f.SetCellValue(name, fmt.Sprintf("%s3", letter), col.Header)
+		w.f.SetCellStyle(name, fmt.Sprintf("%s3", letter), fmt.Sprintf("%s3", letter), w.header)
+		if col.Style != 0 && len(rows) > 0 {
+			w.f.SetCellStyle(name, fmt.Sprintf("%s4", letter), fmt.Sprintf("%s%d", letter, len(rows)+3), col.Style)
+		}
+	}
+	for r, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, r+4)
+		if err := w.f.SetSheetRow(name, cell, &row); err != nil {
+			return err
+		}
+	}
+	return w.f.SetPanes(name, &excelize.Panes{Freeze: true, YSplit: 3, TopLeftCell: "A4", ActivePane: "bottomLeft"})
+}
+
+func (w *workbook) buffer() (*bytes.Buffer, error) {
+	defer w.f.Close()
+	return w.f.WriteToBuffer()
+}
+
+func hexColor(c *props.Color) string {
+	return fmt.Sprintf("%02X%02X%02X", c.Red, c.Green, c.Blue)
+}
+
+func stringPtr(s string) *string { return &s }
+
+// comparisonRow is one summary metric next to its value in the period the
+// report is compared with.
+type comparisonRow struct {
+	label    string
+	current  float64
+	previous float64
+}
+
+// addComparison writes the "Perbandingan" sheet of a report requested with
+// a compare filter. style formats the three value columns; the change is
+// left empty when the compared value is zero.
+func (w *workbook) addComparison(l *localizer, start, end time.Time, style int, metrics []comparisonRow) error {
+	rows := make([][]any, 0, len(metrics))
+	for _, m := range metrics {
+		var change any
+		if m.previous != 0 {
+			change = (m.current - m.previous) / m.previous
+		}
+		rows = append(rows, []any{m.label, m.current, m.previous, m.current - m.previous, change})
+	}
+	columns := []xlsxColumn{
+		{l.Sprintf("Keterangan"), 28, 0},
+		{l.Sprintf("Periode Ini"), 18, style},
+		{l.Sprintf("Pembanding"), 18, style},
+		{l.Sprintf("Selisih"), 18, style},
+		{l.Sprintf("Perubahan"), 12, w.percent},
+	}
+	title := l.Sprintf("Dibandingkan dengan periode %s s.d. %s", l.Date(start), l.Date(end))
+	return w.addTable(l.Sprintf("Perbandingan"), title, columns, rows)
+}
+
+// statRows turns aggregated counts into rows with a share of the total.
+func statRows(l *localizer, stats []domain.DemographicStat, total int64) [][]any {
+	rows := make([][]any, 0, len(stats))
+	for _, stat := range stats {
+		label := stat.ID
+		if label == "" {
+			label = l.Sprintf("Tidak Ditentukan")
+		}
+		share := 0.0
+		if total > 0 {
+			share = float64(stat.Count) / float64(total)
+		}
+		rows = append(rows, []any{label, stat.Count, share})
+	}
+	return rows
+}
+
+func GenerateFinancialXLSX(ctx context.Context, data domain.FinancialReportData) (*bytes.Buffer, error) {
+	l := localeFrom(ctx)
+	w, err := newWorkbook(themeFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	summary := [][]any{
+		{l.Sprintf("Periode Mulai"), data.StartDate},
+		{l.Sprintf("Periode Selesai"), data.EndDate},
+		{l.Sprintf("Total Pemasukan"), data.TotalIncome},
+		{l.Sprintf("Total Pengeluaran"), data.TotalExpenses},
+		{l.Sprintf("Saldo Bersih"), data.NetIncome},
+		{l.Sprintf("Donasi Barang (Estimasi)"), data.TotalInKindValue},
+	}
+	summarySheet := l.Sprintf("Ringkasan")
+	if err := w.addTable(summarySheet, l.Sprintf("Laporan Transparansi Keuangan"), []xlsxColumn{{l.Sprintf("Keterangan"), 28, 0}, {l.Sprintf("Nilai"), 22, w.money}}, summary); err != nil {
+		return nil, err
+	}
+	w.f.SetCellStyle(summarySheet, "B4", "B5", w.date)
+
+	var expenses [][]any
+	for _, stat := range data.ExpensesByCategory {
+		share := 0.0
+		if data.TotalExpenses > 0 {
+			share = stat.Total / data.TotalExpenses
+		}
+		expenses = append(expenses, []any{stat.ID, stat.Total, share})
+	}
+	if err := w.addTable(l.Sprintf("Pengeluaran"), l.Sprintf("Alokasi Pengeluaran"), []xlsxColumn{{l.Sprintf("Kategori"), 30, 0}, {l.Sprintf("Jumlah"), 20, w.money}, {l.Sprintf("Persentase"), 12, w.percent}}, expenses); err != nil {
+		return nil, err
+	}
+
+	var income [][]any
+	for _, stat := range data.IncomeBySource {
+		income = append(income, []any{stat.ID, stat.Total})
+	}
+	if err := w.addTable(l.Sprintf("Pemasukan"), l.Sprintf("Pemasukan per Sumber"), []xlsxColumn{{l.Sprintf("Sumber"), 30, 0}, {l.Sprintf("Jumlah"), 20, w.money}}, income); err != nil {
+		return nil, err
+	}
+
+	var donations [][]any
+	for _, donation := range data.TopDonations {
+		donations = append(donations, []any{donation.Source, donation.Date, donation.Amount})
+	}
+	if err := w.addTable(l.Sprintf("Donasi Teratas"), l.Sprintf("5 Donasi Tunai Teratas"), []xlsxColumn{{l.Sprintf("Sumber"), 30, 0}, {l.Sprintf("Tanggal"), 14, w.date}, {l.Sprintf("Jumlah"), 20, w.money}}, donations); err != nil {
+		return nil, err
+	}
+
+	if c := data.Comparison; c != nil {
+		metrics := []comparisonRow{
+			{l.Sprintf("Total Pemasukan"), data.TotalIncome, c.TotalIncome},
+			{l.Sprintf("Total Pengeluaran"), data.TotalExpenses, c.TotalExpenses},
+			{l.Sprintf("Saldo Bersih"), data.NetIncome, c.NetIncome},
+			{l.Sprintf("Donasi Barang (Estimasi)"), data.TotalInKindValue, c.TotalInKindValue},
+		}
+		if err := w.addComparison(l, c.StartDate, c.EndDate, w.money, metrics); err != nil {
+			return nil, err
+		}
+	}
+	return w.buffer()
+}
+
+func GenerateCommunityActivityXLSX(ctx context.Context, data domain.CommunityActivityData) (*bytes.Buffer, error) {
+	l := localeFrom(ctx)
+	w, err := newWorkbook(themeFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	summary := [][]any{
+		{l.Sprintf("Komunitas"), data.CommunityName},
+		{l.Sprintf("Periode Mulai"), data.StartDate},
+		{l.Sprintf("Periode Selesai"), data.EndDate},
+		{l.Sprintf("Total Kegiatan"), data.EventsHeldCount},
+		{l.Sprintf("Anggota Baru"), data.NewMemberCount},
+		{l.Sprintf("Anggota Aktif"), data.ActiveMemberCount},
+	}
+	summarySheet := l.Sprintf("Ringkasan")
+	if err := w.addTable(summarySheet, l.Sprintf("Laporan Aktivitas Komunitas"), []xlsxColumn{{l.Sprintf("Keterangan"), 24, 0}, {l.Sprintf("Nilai"), 30, 0}}, summary); err != nil {
+		return nil, err
+	}
+	w.f.SetCellStyle(summarySheet, "B5", "B6", w.date)
+
+	var events [][]any
+	for _, event := range data.EventDetails {
+		events = append(events, []any{event.Name, event.Date, event.TutorName, event.ParticipantCount, strings.Join(event.DocumentationURLs, "\n")})
+	}
+	columns := []xlsxColumn{{l.Sprintf("Kegiatan"), 36, 0}, {l.Sprintf("Tanggal"), 14, w.date}, {l.Sprintf("Fasilitator"), 24, 0}, {l.Sprintf("Peserta"), 10, 0}, {l.Sprintf("Dokumentasi"), 60, 0}}
+	if err := w.addTable(l.Sprintf("Kegiatan"), l.Sprintf("Detail Kegiatan & Dokumentasi"), columns, events); err != nil {
+		return nil, err
+	}
+
+	var trend [][]any
+	for _, bucket := range data.Trend {
+		trend = append(trend, []any{bucket.Start, bucket.EventsHeld, bucket.Attendance, bucket.NewMembers})
+	}
+	columns = []xlsxColumn{{l.Sprintf("Mulai"), 14, w.date}, {l.Sprintf("Kegiatan Terlaksana"), 20, 0}, {l.Sprintf("Kehadiran Peserta"), 18, 0}, {l.Sprintf("Anggota Baru"), 14, 0}}
+	if err := w.addTable(l.Sprintf("Tren"), l.Sprintf("Tren Aktivitas"), columns, trend); err != nil {
+		return nil, err
+	}
+
+	if c := data.Comparison; c != nil {
+		metrics := []comparisonRow{
+			{l.Sprintf("Total Kegiatan"), float64(data.EventsHeldCount), float64(c.EventsHeldCount)},
+			{l.Sprintf("Anggota Baru"), float64(data.NewMemberCount), float64(c.NewMemberCount)},
+			{l.Sprintf("Anggota Aktif"), float64(data.ActiveMemberCount), float64(c.ActiveMemberCount)},
+		}
+		if err := w.addComparison(l, c.StartDate, c.EndDate, 0, metrics); err != nil {
+			return nil, err
+		}
+	}
+	return w.buffer()
+}
+
+func GenerateDemographicsXLSX(ctx context.Context, data domain.ParticipantDemographicsData) (*bytes.Buffer, error) {
+	l := localeFrom(ctx)
+	w, err := newWorkbook(themeFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	summary := [][]any{
+		{l.Sprintf("Komunitas"), data.CommunityName},
+		{l.Sprintf("Total Peserta"), data.TotalParticipants},
+	}
+	if err := w.addTable(l.Sprintf("Ringkasan"), l.Sprintf("Laporan Demografi Peserta"), []xlsxColumn{{l.Sprintf("Keterangan"), 24, 0}, {l.Sprintf("Nilai"), 30, 0}}, summary); err != nil {
+		return nil, err
+	}
+	sections := []struct {
+		sheet, title, label string
+		stats               []domain.DemographicStat
+	}{
+		{"Status", "Berdasarkan Status Pekerjaan", "Status", data.ByStatus},
+		{"Usia", "Berdasarkan Kelompok Usia", "Kelompok Usia", data.ByAge},
+		{"Lokasi", "Berdasarkan Lokasi (Top 10)", "Lokasi", data.ByLocation},
+	}
+	for _, s := range sections {
+		columns := []xlsxColumn{{l.Sprintf(s.label), 30, 0}, {l.Sprintf("Jumlah"), 12, 0}, {l.Sprintf("Persentase"), 12, w.percent}}
+		if err := w.addTable(l.Sprintf(s.sheet), l.Sprintf(s.title), columns, statRows(l, s.stats, data.TotalParticipants)); err != nil {
+			return nil, err
+		}
+	}
+	return w.buffer()
+}
+
+// milestoneLabels names the milestone types shown in the impact report.
+var milestoneLabels = map[string]string{
+	"project_submitted": "Proyek Diajukan",
+	"level_up":          "Level Up",
+	"job_placement":     "Penempatan Kerja",
+}
+
+// milestoneLabel returns the translated name of a milestone type, or the
+// raw ID for types without one.
+func milestoneLabel(l *localizer, id string) string {
+	label, ok := milestoneLabels[id]
+	if !ok {
+		return id
+	}
+	return l.Sprintf(label)
+}
+
+func GenerateImpactXLSX(ctx context.Context, data domain.ProgramImpactData) (*bytes.Buffer, error) {
+	l := localeFrom(ctx)
+	w, err := newWorkbook(themeFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	summary := [][]any{
+		{l.Sprintf("Komunitas"), data.CommunityName},
+		{l.Sprintf("Periode Mulai"), data.StartDate},
+		{l.Sprintf("Periode Selesai"), data.EndDate},
+	}
+	summarySheet := l.Sprintf("Ringkasan")
+	if err := w.addTable(summarySheet, l.Sprintf("Laporan Dampak Program"), []xlsxColumn{{l.Sprintf("Keterangan"), 24, 0}, {l.Sprintf("Nilai"), 30, 0}}, summary); err != nil {
+		return nil, err
+	}
+	w.f.SetCellStyle(summarySheet, "B5", "B6", w.date)
+
+	var stats [][]any
+	for _, stat := range data.Stats {
+		stats = append(stats, []any{milestoneLabel(l, stat.ID), stat.Count})
+	}
+	if err := w.addTable(l.Sprintf("Pencapaian"), l.Sprintf("Ringkasan Kinerja"), []xlsxColumn{{l.Sprintf("Pencapaian"), 30, 0}, {l.Sprintf("Jumlah"), 12, 0}}, stats); err != nil {
+		return nil, err
+	}
+
+	var highlights [][]any
+	for _, h := range data.Highlights {
+		highlights = append(highlights, []any{h.Title, h.OwnerName, h.Summary, strings.Join(h.DocumentationURLs, "\n")})
+	}
+	columns := []xlsxColumn{{l.Sprintf("Judul"), 36, 0}, {l.Sprintf("Penanggung Jawab"), 24, 0}, {l.Sprintf("Ringkasan"), 60, 0}, {l.Sprintf("Dokumentasi"), 60, 0}}
+	if err := w.addTable(l.Sprintf("Sorotan"), l.Sprintf("Sorotan Dampak & Dokumentasi"), columns, highlights); err != nil {
+		return nil, err
+	}
+
+	if c := data.Comparison; c != nil {
+		previous := make(map[string]int, len(c.Stats))
+		for _, stat := range c.Stats {
+			previous[stat.ID] = stat.Count
+		}
+		var metrics []comparisonRow
+		for _, stat := range data.Stats {
+			metrics = append(metrics, comparisonRow{milestoneLabel(l, stat.ID), float64(stat.Count), float64(previous[stat.ID])})
+			delete(previous, stat.ID)
+		}
+		// Milestones only reached in the compared period still get a row.
+		for _, stat := range c.Stats {
+			if count, ok := previous[stat.ID]; ok {
+				metrics = append(metrics, comparisonRow{milestoneLabel(l, stat.ID), 0, float64(count)})
+			}
+		}
+		if err := w.addComparison(l, c.StartDate, c.EndDate, 0, metrics); err != nil {
+			return nil, err
+		}
+	}
+	return w.buffer()
+}
@@ -0,0 +1,114 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"org-worker/internal/domain"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// sheetRows opens a generated workbook and returns the sheet's rows below
+// the title and header, as excelize reads them back.
+func sheetRows(t *testing.T, buf *bytes.Buffer, sheet string) ([][]string, bool) {
+	t.Helper()
+	f, err := excelize.OpenReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if !slices.Contains(f.GetSheetList(), sheet) {
+		return nil, false
+	}
+	rows, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows[3:], true
+}
+
+func TestXLSXComparisonSheet(t *testing.T) {
+	may := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	aprilStart, aprilEnd := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 4, 30, 23, 59, 59, 0, time.UTC)
+
+	financial := domain.FinancialReportData{
+		StartDate: may, EndDate: may.AddDate(0, 1, 0),
+		TotalIncome: 1500, TotalExpenses: 300, NetIncome: 1200,
+		Comparison: &domain.FinancialComparison{StartDate: aprilStart, EndDate: aprilEnd, TotalIncome: 1000, TotalExpenses: 400, NetIncome: 600},
+	}
+	activity := domain.CommunityActivityData{
+		StartDate: may, EndDate: may.AddDate(0, 1, 0),
+		EventsHeldCount: 4, NewMemberCount: 2, ActiveMemberCount: 10,
+		Comparison: &domain.ActivityComparison{StartDate: aprilStart, EndDate: aprilEnd, EventsHeldCount: 2, NewMemberCount: 0, ActiveMemberCount: 8},
+	}
+	impact := domain.ProgramImpactData{
+		StartDate: may, EndDate: may.AddDate(0, 1, 0),
+		Stats: []domain.MilestoneStat{{ID: "level_up", Count: 6}},
+		Comparison: &domain.ImpactComparison{StartDate: aprilStart, EndDate: aprilEnd, Stats: []domain.MilestoneStat{
+			{ID: "level_up", Count: 4}, {ID: "job_placement", Count: 1},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		generate func(context.Context) (*bytes.Buffer, error)
+		want     [][]string
+	}{
+		{
+			"financial",
+			func(ctx context.Context) (*bytes.Buffer, error) { return GenerateFinancialXLSX(ctx, financial) },
+			[][]string{
+				{"Total Pemasukan", "1500", "1000", "500", "0.5"},
+				{"Total Pengeluaran", "300", "400", "-100", "-0.25"},
+				{"Saldo Bersih", "1200", "600", "600", "1"},
+				{"Donasi Barang (Estimasi)", "0", "0", "0"},
+			},
+		},
+		{
+			"community activity",
+			func(ctx context.Context) (*bytes.Buffer, error) { return GenerateCommunityActivityXLSX(ctx, activity) },
+			[][]string{
+				{"Total Kegiatan", "4", "2", "2", "1"},
+				{"Anggota Baru", "2", "0", "2"},
+				{"Anggota Aktif", "10", "8", "2", "0.25"},
+			},
+		},
+		{
+			"program impact",
+			func(ctx context.Context) (*bytes.Buffer, error) { return GenerateImpactXLSX(ctx, impact) },
+			[][]string{
+				{"Level Up", "6", "4", "2", "0.5"},
+				{"Penempatan Kerja", "0", "1", "-1", "-1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := tt.generate(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows, ok := sheetRows(t, buf, "Perbandingan")
+			if !ok {
+				t.Fatal("no Perbandingan sheet")
+			}
+			if !slices.EqualFunc(rows, tt.want, slices.Equal[[]string]) {
+				t.Errorf("rows = %q, want %q", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestXLSXWithoutComparison(t *testing.T) {
+	buf, err := GenerateFinancialXLSX(context.Background(), domain.FinancialReportData{TotalIncome: 1500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sheetRows(t, buf, "Perbandingan"); ok {
+		t.Error("Perbandingan sheet written without a comparison")
+	}
+}
@@ -0,0 +1,82 @@
+package progress
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"org-worker/internal/domain"
+)
+
+// Stage names written to the tracking document's progress sub-document.
+const (
+	StageFetchingData      = "fetching_data"
+	StageDownloadingImages = "downloading_images"
+	StageProcessing        = "processing"
+	StageRendering         = "rendering"
+	StageUploading         = "uploading"
+	StageDelivering        = "delivering"
+	StageCompleted         = "completed"
+)
+
+// MinInterval is the shortest gap between two writes within the same stage.
+var MinInterval = 2 * time.Second
+
+// WriteFunc persists a progress snapshot on the tracking document.
+type WriteFunc func(ctx context.Context, p domain.Progress) error
+
+// Reporter throttles progress updates for one job: a write happens when the
+// stage changes, the job reaches 100%, or MinInterval has passed since the
+// previous write. The written percent never goes down, so rendering a second
+// output format does not move the bar back.
+type Reporter struct {
+	write WriteFunc
+
+	mu          sync.Mutex
+	lastStage   string
+	lastWrite   time.Time
+	lastPercent int
+}
+
+func NewReporter(write WriteFunc) *Reporter {
+	return &Reporter{write: write}
+}
+
+func (r *Reporter) Update(ctx context.Context, stage string, percent int, step string) {
+	if r == nil {
+		return
+	}
+	now := time.Now()
+	r.mu.Lock()
+	if stage == r.lastStage && percent < 100 && now.Sub(r.lastWrite) < MinInterval {
+		r.mu.Unlock()
+		return
+	}
+	percent = max(min(percent, 100), r.lastPercent)
+	r.lastStage = stage
+	r.lastWrite = now
+	r.lastPercent = percent
+	r.mu.Unlock()
+
+	// Progress is best effort; a failed write must not fail the job.
+	_ = r.write(ctx, domain.Progress{
+		Stage:     stage,
+		Percent:   percent,
+		Step:      step,
+		UpdatedAt: now,
+	})
+}
+
+type reporterKey struct{}
+
+// WithReporter attaches r to ctx so code deep in a handler (e.g. PDF
+// renderers) can report progress without extra parameters.
+func WithReporter(ctx context.Context, r *Reporter) context.Context {
+	return context.WithValue(ctx, reporterKey{}, r)
+}
+
+// Report updates the reporter attached to ctx, if any.
+func Report(ctx context.Context, stage string, percent int, step string) {
+	r, _ := ctx.Value(reporterKey{}).(*Reporter)
+	r.Update(ctx, stage, percent, step)
+}
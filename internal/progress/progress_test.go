@@ -0,0 +1,84 @@
+package progress
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"org-worker/internal/domain"
+)
+
+func TestReporterUpdate(t *testing.T) {
+	type update struct {
+		stage   string
+		percent int
+	}
+	tests := []struct {
+		name     string
+		interval time.Duration
+		updates  []update
+		want     []string
+	}{
+		{
+			name:     "throttled within a stage",
+			interval: time.Hour,
+			updates:  []update{{StageDownloadingImages, 30}, {StageDownloadingImages, 40}, {StageDownloadingImages, 50}},
+			want:     []string{"downloading_images 30"},
+		},
+		{
+			name:     "interval passed",
+			interval: 0,
+			updates:  []update{{StageDownloadingImages, 30}, {StageDownloadingImages, 40}},
+			want:     []string{"downloading_images 30", "downloading_images 40"},
+		},
+		{
+			name:     "stage change always written",
+			interval: time.Hour,
+			updates:  []update{{StageFetchingData, 5}, {StageRendering, 30}, {StageUploading, 90}},
+			want:     []string{"fetching_data 5", "rendering 30", "uploading 90"},
+		},
+		{
+			name:     "100 percent always written",
+			interval: time.Hour,
+			updates:  []update{{StageRendering, 30}, {StageRendering, 100}},
+			want:     []string{"rendering 30", "rendering 100"},
+		},
+		{
+			name:     "percent never goes down",
+			interval: 0,
+			updates:  []update{{StageDownloadingImages, 85}, {StageRendering, 60}, {StageRendering, 90}},
+			want:     []string{"downloading_images 85", "rendering 85", "rendering 90"},
+		},
+		{
+			name:     "percent clamped",
+			interval: 0,
+			updates:  []update{{StageFetchingData, -5}, {StageCompleted, 120}},
+			want:     []string{"fetching_data 0", "completed 100"},
+		},
+	}
+	defer func(interval time.Duration) { MinInterval = interval }(MinInterval)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			MinInterval = tt.interval
+			var got []string
+			r := NewReporter(func(_ context.Context, p domain.Progress) error {
+				got = append(got, fmt.Sprintf("%s %d", p.Stage, p.Percent))
+				return nil
+			})
+			for _, u := range tt.updates {
+				r.Update(context.Background(), u.stage, u.percent, "")
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("writes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportWithoutReporter(t *testing.T) {
+	// Handlers report unconditionally; a context without a reporter is a
+	// no-op rather than a nil dereference.
+	Report(context.Background(), StageRendering, 50, "rendering")
+}
@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Consumer hands raw jobs from a Redis list to the worker loop.
+type Consumer interface {
+	// Pop waits for the next job. An empty string with a nil error means the
+	// wait timed out and the caller should try again.
+	Pop(ctx context.Context) (string, error)
+	// Ack tells the queue the job is done and must not be redelivered.
+	Ack(ctx context.Context, data string) error
+	// Requeue gives an unfinished job back so another worker can pick it up.
+	Requeue(ctx context.Context, data string) error
+	// Close releases whatever the consumer registered in Redis.
+	Close(ctx context.Context) error
+}
+
+// SimpleQueue is the original at-most-once mode: BLPOP removes the job from
+// Redis before any work happens.
+type SimpleQueue struct {
+	client *redis.Client
+	name   string
+	lanes  *Lanes
+}
+
+func NewSimpleQueue(client *redis.Client, name string, lanes *Lanes) *SimpleQueue {
+	return &SimpleQueue{client: client, name: name, lanes: lanes}
+}
+
+// Pop tries every lane without blocking in weighted order, then blocks on
+// all of them at once with the heaviest lane first.
+func (q *SimpleQueue) Pop(ctx context.Context) (string, error) {
+	order := q.lanes.Order()
+	if len(order) > 1 {
+		for _, key := range order {
+			data, err := q.client.LPop(ctx, key).Result()
+			if err == nil {
+				return data, nil
+			}
+			if err != redis.Nil {
+				return "", err
+			}
+		}
+	}
+	result, err := BLPop(q.client, ctx, q.lanes.Keys()...)
+	if err != nil || len(result) < 2 {
+		return "", err
+	}
+	return result[1], nil
+}
+
+func (q *SimpleQueue) Ack(ctx context.Context, data string) error {
+	return nil
+}
+
+func (q *SimpleQueue) Requeue(ctx context.Context, data string) error {
+	return Push(q.client, ctx, q.name, data)
+}
+
+func (q *SimpleQueue) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DelayedKey and DeadKey name the sorted set of jobs waiting for a retry and
+// the list of jobs that will not be attempted again.
+func DelayedKey(queueName string) string { return queueName + ":delayed" }
+func DeadKey(queueName string) string    { return queueName + ":dead" }
+
+// moveDueScript moves due jobs from the delayed set to the queue in one step
+// so concurrent workers never push the same job twice.
+var moveDueScript = redis.NewScript(`
+local items = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, item in ipairs(items) do
+	redis.call('ZREM', KEYS[1], item)
+	redis.call('LPUSH', KEYS[2], item)
+end
+return #items
+`)
+
+// Schedule stores a job in the delayed set until at.
+func Schedule(client *redis.Client, ctx context.Context, queueName, data string, at time.Time) error {
+	return client.ZAdd(ctx, DelayedKey(queueName), &redis.Z{
+		Score:  float64(at.UnixMilli()),
+		Member: data,
+	}).Err()
+}
+
+// RunDelayedMover pushes jobs whose retry time has passed back onto the
+// queue until ctx is cancelled.
+func RunDelayedMover(ctx context.Context, client *redis.Client, logger *slog.Logger, queueName string) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+			moved, err := moveDueScript.Run(ctx, client, []string{DelayedKey(queueName), queueName}, now).Int()
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Error("Failed to move delayed jobs", "err", err)
+				}
+				continue
+			}
+			if moved > 0 {
+				logger.Info("Re-enqueued delayed jobs", "count", moved)
+			}
+		}
+	}
+}
+
+// DeadLetterEntry is what ends up in the dead-letter list: the original job
+// plus enough context to decide whether to replay it.
+type DeadLetterEntry struct {
+	Job      json.RawMessage `json:"job"`
+	Reason   string          `json:"reason"`
+	Attempts int             `json:"attempts,omitempty"`
+	FailedAt time.Time       `json:"failedAt"`
+}
+
+// DeadLetter records a job that exhausted its retries or can never succeed.
+func DeadLetter(client *redis.Client, ctx context.Context, queueName, data, reason string, attempts int) error {
+	job := json.RawMessage(data)
+	if !json.Valid(job) {
+		quoted, _ := json.Marshal(data)
+		job = quoted
+	}
+	entry, err := json.Marshal(DeadLetterEntry{
+		Job:      job,
+		Reason:   reason,
+		Attempts: attempts,
+		FailedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return client.LPush(ctx, DeadKey(queueName), entry).Err()
+}
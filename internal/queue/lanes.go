@@ -0,0 +1,204 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// reservedLaneNames collide with keys the worker already uses under the
+// queue prefix.
+var reservedLaneNames = map[string]bool{
+	"delayed": true, "dead": true, "processing": true, "heartbeat": true,
+	"workers": true, "cancel": true, "scheduler": true,
+	"events": true,
+}
+
+// Lane is one priority queue, stored at <queue>:<name>.
+type Lane struct {
+	Name   string
+	Key    string
+	Weight int
+}
+
+// Lanes splits consumption of a queue into weighted priority lanes. Jobs
+// still enter through the plain queue key (the intake) and a router moves
+// them to a lane chosen by the job's "lane" field or its task type. Without
+// configured lanes the intake itself is the only lane.
+type Lanes struct {
+	intake      string
+	lanes       []Lane
+	byName      map[string]string
+	taskLanes   map[string]string
+	defaultLane string
+
+	mu      sync.Mutex
+	current []int
+}
+
+// SingleLane consumes the intake directly, as before lanes existed.
+func SingleLane(intake string) *Lanes {
+	return &Lanes{
+		intake:  intake,
+		lanes:   []Lane{{Key: intake, Weight: 1}},
+		current: make([]int, 1),
+	}
+}
+
+// ParseLanes builds lanes from specs like "high:6,default:3,bulk:1" and
+// "generate_report:high,process_image:bulk". Unmapped task types go to the
+// "default" lane if there is one, otherwise to the heaviest lane.
+func ParseLanes(intake, laneSpec, taskSpec string) (*Lanes, error) {
+	if strings.TrimSpace(laneSpec) == "" {
+		return SingleLane(intake), nil
+	}
+	l := &Lanes{intake: intake, byName: map[string]string{}, taskLanes: map[string]string{}}
+	for _, part := range strings.Split(laneSpec, ",") {
+		name, weightStr, _ := strings.Cut(strings.TrimSpace(part), ":")
+		name = strings.TrimSpace(name)
+		if name == "" || reservedLaneNames[name] {
+			return nil, fmt.Errorf("invalid lane name %q", name)
+		}
+		weight := 1
+		if weightStr != "" {
+			parsed, err := strconv.Atoi(strings.TrimSpace(weightStr))
+			if err != nil || parsed < 1 {
+				return nil, fmt.Errorf("invalid weight for lane %q", name)
+			}
+			weight = parsed
+		}
+		if _, dup := l.byName[name]; dup {
+			return nil, fmt.Errorf("lane %q configured twice", name)
+		}
+		key := intake + ":" + name
+		l.lanes = append(l.lanes, Lane{Name: name, Key: key, Weight: weight})
+		l.byName[name] = key
+	}
+	sort.SliceStable(l.lanes, func(i, j int) bool { return l.lanes[i].Weight > l.lanes[j].Weight })
+	l.current = make([]int, len(l.lanes))
+
+	l.defaultLane = l.lanes[0].Key
+	if key, ok := l.byName["default"]; ok {
+		l.defaultLane = key
+	}
+	for _, part := range strings.Split(taskSpec, ",") {
+		taskType, lane, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			continue
+		}
+		key, exists := l.byName[strings.TrimSpace(lane)]
+		if !exists {
+			return nil, fmt.Errorf("task type %q mapped to unknown lane %q", taskType, lane)
+		}
+		l.taskLanes[strings.TrimSpace(taskType)] = key
+	}
+	return l, nil
+}
+
+// Routed reports whether jobs need to be moved from the intake to lanes.
+func (l *Lanes) Routed() bool {
+	return len(l.byName) > 0
+}
+
+// Keys lists the lane keys by descending weight.
+func (l *Lanes) Keys() []string {
+	keys := make([]string, 0, len(l.lanes))
+	for _, lane := range l.lanes {
+		keys = append(keys, lane.Key)
+	}
+	return keys
+}
+
+// Order returns the lane keys to try for the next pop. The first key is
+// picked by smooth weighted round-robin so every lane gets its share even
+// under load; the rest follow by weight as fallbacks.
+func (l *Lanes) Order() []string {
+	if len(l.lanes) == 1 {
+		return []string{l.lanes[0].Key}
+	}
+	l.mu.Lock()
+	total, best := 0, 0
+	for i, lane := range l.lanes {
+		l.current[i] += lane.Weight
+		total += lane.Weight
+		if l.current[i] > l.current[best] {
+			best = i
+		}
+	}
+	l.current[best] -= total
+	l.mu.Unlock()
+
+	keys := make([]string, 0, len(l.lanes))
+	keys = append(keys, l.lanes[best].Key)
+	for i, lane := range l.lanes {
+		if i != best {
+			keys = append(keys, lane.Key)
+		}
+	}
+	return keys
+}
+
+// routeScript moves up to ARGV[1] jobs from the intake to their lane in a
+// single step, so a crash never loses a job between the two lists.
+var routeScript = redis.NewScript(`
+local taskLanes = cjson.decode(ARGV[3])
+local laneKeys = cjson.decode(ARGV[4])
+local moved = 0
+for i = 1, tonumber(ARGV[1]) do
+	local item = redis.call('RPOP', KEYS[1])
+	if not item then break end
+	local target = ARGV[2]
+	local ok, job = pcall(cjson.decode, item)
+	if ok and type(job) == 'table' then
+		if type(job.lane) == 'string' and laneKeys[job.lane] then
+			target = laneKeys[job.lane]
+		elseif type(job.task_type) == 'string' and taskLanes[job.task_type] then
+			target = taskLanes[job.task_type]
+		end
+	end
+	redis.call('LPUSH', target, item)
+	moved = moved + 1
+end
+return moved
+`)
+
+// RunRouter moves jobs from the intake into lanes until ctx is cancelled.
+// Every worker runs one; the script keeps concurrent routers safe.
+func (l *Lanes) RunRouter(ctx context.Context, client *redis.Client, logger *slog.Logger) {
+	taskLanes, _ := json.Marshal(nonNilMap(l.taskLanes))
+	laneKeys, _ := json.Marshal(nonNilMap(l.byName))
+	for ctx.Err() == nil {
+		moved, err := routeScript.Run(ctx, client, []string{l.intake}, 100, l.defaultLane, string(taskLanes), string(laneKeys)).Int()
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Error("Failed to route jobs to lanes", "err", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		if moved > 0 {
+			continue
+		}
+		// Moving the tail onto itself changes nothing but blocks until the
+		// intake has something in it.
+		if err := client.BLMove(ctx, l.intake, l.intake, "RIGHT", "RIGHT", popTimeout).Err(); err != nil && err != redis.Nil && ctx.Err() == nil {
+			logger.Error("Failed to wait on intake queue", "err", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func nonNilMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
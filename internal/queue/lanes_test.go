@@ -0,0 +1,132 @@
+package queue
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseLanes(t *testing.T) {
+	tests := []struct {
+		name        string
+		laneSpec    string
+		taskSpec    string
+		wantKeys    []string
+		wantDefault string
+		wantTasks   map[string]string
+		wantErr     bool
+	}{
+		{
+			name:     "no lanes",
+			wantKeys: []string{"q"},
+		},
+		{
+			name:        "sorted by weight with a default lane",
+			laneSpec:    "bulk:1, default:3, high:6",
+			taskSpec:    "generate_report:high,process_image:bulk",
+			wantKeys:    []string{"q:high", "q:default", "q:bulk"},
+			wantDefault: "q:default",
+			wantTasks:   map[string]string{"generate_report": "q:high", "process_image": "q:bulk"},
+		},
+		{
+			name:        "heaviest lane is the default without one named default",
+			laneSpec:    "slow:1,fast:4",
+			wantKeys:    []string{"q:fast", "q:slow"},
+			wantDefault: "q:fast",
+			wantTasks:   map[string]string{},
+		},
+		{
+			name:        "weight defaults to one",
+			laneSpec:    "a,b:2",
+			wantKeys:    []string{"q:b", "q:a"},
+			wantDefault: "q:b",
+			wantTasks:   map[string]string{},
+		},
+		{name: "reserved name", laneSpec: "delayed:2", wantErr: true},
+		{name: "empty name", laneSpec: "high:2,:1", wantErr: true},
+		{name: "zero weight", laneSpec: "high:0", wantErr: true},
+		{name: "weight not a number", laneSpec: "high:x", wantErr: true},
+		{name: "duplicate lane", laneSpec: "high:2,high:1", wantErr: true},
+		{name: "task type on unknown lane", laneSpec: "high:2", taskSpec: "process_image:bulk", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lanes, err := ParseLanes("q", tt.laneSpec, tt.taskSpec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseLanes(%q, %q) succeeded, want an error", tt.laneSpec, tt.taskSpec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLanes(%q, %q): %v", tt.laneSpec, tt.taskSpec, err)
+			}
+			if got := lanes.Keys(); !slices.Equal(got, tt.wantKeys) {
+				t.Errorf("Keys() = %v, want %v", got, tt.wantKeys)
+			}
+			if lanes.defaultLane != tt.wantDefault {
+				t.Errorf("default lane = %q, want %q", lanes.defaultLane, tt.wantDefault)
+			}
+			if tt.wantTasks != nil && len(lanes.taskLanes) != len(tt.wantTasks) {
+				t.Errorf("task lanes = %v, want %v", lanes.taskLanes, tt.wantTasks)
+			}
+			for taskType, key := range tt.wantTasks {
+				if lanes.taskLanes[taskType] != key {
+					t.Errorf("task %s routed to %q, want %q", taskType, lanes.taskLanes[taskType], key)
+				}
+			}
+			if got, want := lanes.Routed(), tt.laneSpec != ""; got != want {
+				t.Errorf("Routed() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLanesOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		laneSpec string
+		// firsts is the lane expected first on consecutive pops.
+		firsts []string
+	}{
+		{"single lane", "", []string{"q", "q", "q"}},
+		{"3:1", "high:3,low:1", []string{"q:high", "q:high", "q:low", "q:high", "q:high", "q:high", "q:low", "q:high"}},
+		{"smooth 5:1:1", "a:5,b:1,c:1", []string{"q:a", "q:a", "q:b", "q:a", "q:c", "q:a", "q:a"}},
+		{"equal weights alternate", "x:1,y:1", []string{"q:x", "q:y", "q:x", "q:y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lanes, err := ParseLanes("q", tt.laneSpec, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.firsts {
+				order := lanes.Order()
+				if order[0] != want {
+					t.Fatalf("pop %d: first lane %q, want %q (order %v)", i, order[0], want, order)
+				}
+				// Every lane stays reachable as a fallback, heaviest first.
+				rest := slices.DeleteFunc(lanes.Keys(), func(k string) bool { return k == want })
+				if !slices.Equal(order[1:], rest) {
+					t.Fatalf("pop %d: fallbacks %v, want %v", i, order[1:], rest)
+				}
+			}
+		})
+	}
+}
+
+func TestLanesOrderShares(t *testing.T) {
+	lanes, err := ParseLanes("q", "high:6,default:3,bulk:1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for i := 0; i < 100; i++ {
+		counts[lanes.Order()[0]]++
+	}
+	want := map[string]int{"q:high": 60, "q:default": 30, "q:bulk": 10}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Errorf("%s picked first %d times out of 100, want %d", key, counts[key], n)
+		}
+	}
+}
@@ -2,10 +2,28 @@ package queue
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
-func BLPop(client *redis.Client, ctx context.Context, queueName string) ([]string, error) {
-	return client.BLPop(ctx, 0, queueName).Result()
+// popTimeout bounds each blocking pop so the caller can notice a cancelled
+// context; go-redis does not interrupt a BLPOP that is already waiting.
+const popTimeout = 5 * time.Second
+
+// BLPop waits for the next job on the given queues, checked in order. It
+// returns a nil result and no error when the wait times out so callers can
+// simply loop again.
+func BLPop(client *redis.Client, ctx context.Context, queueNames ...string) ([]string, error) {
+	result, err := client.BLPop(ctx, popTimeout, queueNames...).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return result, err
+}
+
+// Push puts a raw job back on the consuming end of the queue, the same way
+// producers enqueue with LPUSH.
+func Push(client *redis.Client, ctx context.Context, queueName, data string) error {
+	return client.LPush(ctx, queueName, data).Err()
 }
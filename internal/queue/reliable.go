@@ -0,0 +1,184 @@
+package queue
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ReliableQueue moves each job into a per-worker processing list with BLMOVE
+// instead of removing it. The job only leaves Redis once it is acknowledged,
+// so a crash or OOM kill leaves it in the processing list where the reaper
+// of any live worker will find it and push it back onto the source queue.
+type ReliableQueue struct {
+	client            *redis.Client
+	name              string
+	lanes             *Lanes
+	workerID          string
+	visibilityTimeout time.Duration
+}
+
+func NewReliableQueue(client *redis.Client, name string, lanes *Lanes, workerID string, visibilityTimeout time.Duration) *ReliableQueue {
+	return &ReliableQueue{
+		client:            client,
+		name:              name,
+		lanes:             lanes,
+		workerID:          workerID,
+		visibilityTimeout: visibilityTimeout,
+	}
+}
+
+func (q *ReliableQueue) processingKey(workerID string) string {
+	return q.name + ":processing:" + workerID
+}
+
+func (q *ReliableQueue) heartbeatKey(workerID string) string {
+	return q.name + ":heartbeat:" + workerID
+}
+
+func (q *ReliableQueue) workersKey() string {
+	return q.name + ":workers"
+}
+
+// laneIdleWait bounds the blocking move used when every lane is empty. BLMOVE
+// only watches one list, so the other lanes are rechecked this often.
+const laneIdleWait = time.Second
+
+// Pop moves the next job from the head of a lane into this worker's
+// processing list, matching the LPUSH/BLPOP order producers already rely on.
+// With several lanes each is tried without blocking in weighted order before
+// waiting briefly on the lane picked first.
+func (q *ReliableQueue) Pop(ctx context.Context) (string, error) {
+	order := q.lanes.Order()
+	wait := popTimeout
+	if len(order) > 1 {
+		for _, key := range order {
+			data, err := q.client.LMove(ctx, key, q.processingKey(q.workerID), "LEFT", "RIGHT").Result()
+			if err == nil {
+				return data, nil
+			}
+			if err != redis.Nil {
+				return "", err
+			}
+		}
+		wait = laneIdleWait
+	}
+	data, err := q.client.BLMove(ctx, order[0], q.processingKey(q.workerID), "LEFT", "RIGHT", wait).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return data, err
+}
+
+func (q *ReliableQueue) Ack(ctx context.Context, data string) error {
+	return q.client.LRem(ctx, q.processingKey(q.workerID), 1, data).Err()
+}
+
+func (q *ReliableQueue) Requeue(ctx context.Context, data string) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, q.processingKey(q.workerID), 1, data)
+		pipe.LPush(ctx, q.name, data)
+		return nil
+	})
+	return err
+}
+
+// Start registers the worker, recovers jobs left behind by a previous run
+// with the same worker ID, and keeps the heartbeat and reaper running until
+// ctx is cancelled.
+func (q *ReliableQueue) Start(ctx context.Context, logger *slog.Logger) error {
+	if err := q.client.SAdd(ctx, q.workersKey(), q.workerID).Err(); err != nil {
+		return err
+	}
+	if moved, err := q.recover(ctx, q.workerID); err != nil {
+		return err
+	} else if moved > 0 {
+		logger.Warn("Recovered jobs from previous run", "workerID", q.workerID, "count", moved)
+	}
+	if err := q.beat(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		heartbeat := time.NewTicker(q.visibilityTimeout / 3)
+		reaper := time.NewTicker(q.visibilityTimeout)
+		defer heartbeat.Stop()
+		defer reaper.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-heartbeat.C:
+				if err := q.beat(ctx); err != nil && ctx.Err() == nil {
+					logger.Error("Failed to refresh worker heartbeat", "err", err)
+				}
+			case <-reaper.C:
+				q.reap(ctx, logger)
+			}
+		}
+	}()
+	return nil
+}
+
+// Close deregisters the worker after a clean shutdown. Anything still left
+// in its processing list is picked up by the next reaper run.
+func (q *ReliableQueue) Close(ctx context.Context) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, q.heartbeatKey(q.workerID))
+		pipe.SRem(ctx, q.workersKey(), q.workerID)
+		return nil
+	})
+	return err
+}
+
+func (q *ReliableQueue) beat(ctx context.Context) error {
+	return q.client.Set(ctx, q.heartbeatKey(q.workerID), time.Now().Unix(), q.visibilityTimeout).Err()
+}
+
+// reap returns the processing lists of workers whose heartbeat key is gone
+// back to the source queue. The key lives for one visibility timeout after
+// the last beat, so a worker is reaped as soon as it missed beats for that
+// long, not twice as long.
+func (q *ReliableQueue) reap(ctx context.Context, logger *slog.Logger) {
+	workers, err := q.client.SMembers(ctx, q.workersKey()).Result()
+	if err != nil {
+		logger.Error("Reaper failed to list workers", "err", err)
+		return
+	}
+	for _, workerID := range workers {
+		if workerID == q.workerID {
+			continue
+		}
+		alive, err := q.client.Exists(ctx, q.heartbeatKey(workerID)).Result()
+		if err != nil || alive > 0 {
+			continue
+		}
+		moved, err := q.recover(ctx, workerID)
+		if err != nil {
+			logger.Error("Reaper failed to recover jobs", "workerID", workerID, "err", err)
+			continue
+		}
+		// Only forget the worker once its list is empty, otherwise the next
+		// run retries whatever could not be moved.
+		q.client.SRem(ctx, q.workersKey(), workerID)
+		if moved > 0 {
+			logger.Warn("Re-queued jobs from dead worker", "workerID", workerID, "count", moved)
+		}
+	}
+}
+
+func (q *ReliableQueue) recover(ctx context.Context, workerID string) (int, error) {
+	moved := 0
+	for {
+		err := q.client.LMove(ctx, q.processingKey(workerID), q.name, "RIGHT", "LEFT").Err()
+		if err == redis.Nil {
+			return moved, nil
+		}
+		if err != nil {
+			return moved, err
+		}
+		moved++
+	}
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"org-worker/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetBranding returns the branding documents that apply to a community: the
+// organization default (no community) first, then the community's own
+// override if there is one. An empty community only matches the default.
+func (r *ReportRepository) GetBranding(ctx context.Context, community string) ([]domain.Branding, error) {
+	scopes := bson.A{
+		bson.M{"community": bson.M{"$exists": false}},
+		bson.M{"community": ""},
+	}
+	if community != "" {
+		scopes = append(scopes, bson.M{"community": community})
+	}
+	cursor, err := r.db.Collection("branding").Find(ctx, bson.M{"$or": scopes}, options.Find().SetSort(bson.M{"community": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []domain.Branding
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
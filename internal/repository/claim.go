@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"org-worker/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// claimableStatuses can be picked up by any delivery of the job. Image jobs
+// created by older clients still use upper-case "PENDING".
+var claimableStatuses = bson.A{nil, "", "pending", "PENDING", "retrying", "interrupted"}
+
+// unforceableStatuses cannot be claimed even by a forced job: someone else
+// owns the document or it was cancelled on purpose.
+var unforceableStatuses = bson.A{"processing", "cancel_requested", "cancelled"}
+
+// ownedFilter matches the document only while owner holds the claim on it.
+func ownedFilter(id primitive.ObjectID, owner string) bson.M {
+	return bson.M{"_id": id, "status": "processing", "workerID": owner}
+}
+
+// statusFilter matches the document a worker may move to status. Only the
+// claim owner writes outcomes, except that a cancellation also answers a
+// cancel_requested set while the job ran, or before anyone claimed it
+// (owner "").
+func statusFilter(id primitive.ObjectID, owner, status string) bson.M {
+	if status != "cancelled" {
+		return ownedFilter(id, owner)
+	}
+	if owner == "" {
+		return bson.M{"_id": id, "status": bson.M{"$in": bson.A{"cancel_requested", "cancelled"}}}
+	}
+	return bson.M{"_id": id, "workerID": owner, "status": bson.M{"$in": bson.A{"processing", "cancel_requested"}}}
+}
+
+// updateOwned applies update to the document matched by filter and fails
+// with domain.ErrLeaseLost when nothing matched.
+func updateOwned(ctx context.Context, coll *mongo.Collection, filter, update bson.M) error {
+	res, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrLeaseLost
+	}
+	return nil
+}
+
+// claimFilter matches the document a worker may claim at now. A
+// "processing" document whose lease expired (its worker crashed) is
+// claimable again.
+func claimFilter(id primitive.ObjectID, now time.Time, force bool) bson.M {
+	statusFilter := bson.M{"status": bson.M{"$in": claimableStatuses}}
+	if force {
+		statusFilter = bson.M{"status": bson.M{"$nin": unforceableStatuses}}
+	}
+	return bson.M{
+		"_id": id,
+		"$or": bson.A{
+			statusFilter,
+			bson.M{"status": "processing", "leaseExpiresAt": bson.M{"$not": bson.M{"$gte": now}}},
+		},
+	}
+}
+
+// claimDocument atomically moves a tracking document to "processing" and
+// records the owner and lease.
+func claimDocument(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID, owner string, leaseUntil time.Time, force bool) error {
+	now := time.Now()
+	filter := claimFilter(id, now, force)
+	update := bson.M{"$set": bson.M{
+		"status":         "processing",
+		"workerID":       owner,
+		"leaseExpiresAt": leaseUntil,
+		"claimedAt":      now,
+		"updatedAt":      now,
+	}}
+	res, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to claim job: %w", err)
+	}
+	if res.MatchedCount == 1 {
+		return nil
+	}
+
+	var current struct {
+		Status     string    `bson:"status"`
+		WorkerID   string    `bson:"workerID"`
+		LeaseUntil time.Time `bson:"leaseExpiresAt"`
+	}
+	if err := coll.FindOne(ctx, bson.M{"_id": id}).Decode(&current); err != nil {
+		return err
+	}
+	if current.Status == "processing" {
+		return &domain.LeaseHeldError{Owner: current.WorkerID, Until: current.LeaseUntil}
+	}
+	return fmt.Errorf("%w: status is %q", domain.ErrNotClaimable, current.Status)
+}
+
+// releaseDocument hands a claimed document back to "pending", but only if
+// owner still holds it.
+func releaseDocument(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID, owner string) error {
+	_, err := coll.UpdateOne(ctx,
+		bson.M{"_id": id, "status": "processing", "workerID": owner},
+		bson.M{
+			"$set":   bson.M{"status": "pending", "updatedAt": time.Now()},
+			"$unset": bson.M{"leaseExpiresAt": ""},
+		},
+	)
+	return err
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// matches evaluates the subset of the query language the claim filters use
+// against doc, with missing fields reading as nil like they do in Mongo.
+func matches(t *testing.T, filter, doc bson.M) bool {
+	t.Helper()
+	for key, cond := range filter {
+		if key == "$or" {
+			matched := false
+			for _, sub := range cond.(bson.A) {
+				matched = matched || matches(t, sub.(bson.M), doc)
+			}
+			if !matched {
+				return false
+			}
+			continue
+		}
+		if !fieldMatches(t, doc[key], cond) {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldMatches(t *testing.T, v, cond any) bool {
+	t.Helper()
+	ops, ok := cond.(bson.M)
+	if !ok {
+		return v == cond
+	}
+	for op, arg := range ops {
+		var ok bool
+		switch op {
+		case "$in":
+			ok = contains(arg.(bson.A), v)
+		case "$nin":
+			ok = !contains(arg.(bson.A), v)
+		case "$not":
+			ok = !fieldMatches(t, v, arg)
+		case "$gte":
+			tv, isTime := v.(time.Time)
+			ok = isTime && !tv.Before(arg.(time.Time))
+		default:
+			t.Fatalf("unsupported operator %s", op)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list bson.A, v any) bool {
+	for _, e := range list {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func TestClaimFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	doc := func(fields bson.M) bson.M {
+		fields["_id"] = id
+		return fields
+	}
+	tests := []struct {
+		name      string
+		doc       bson.M
+		want      bool
+		wantForce bool
+	}{
+		{"no status", doc(bson.M{}), true, true},
+		{"empty status", doc(bson.M{"status": ""}), true, true},
+		{"pending", doc(bson.M{"status": "pending"}), true, true},
+		{"upper-case pending", doc(bson.M{"status": "PENDING"}), true, true},
+		{"retrying", doc(bson.M{"status": "retrying"}), true, true},
+		{"interrupted", doc(bson.M{"status": "interrupted"}), true, true},
+		{"completed", doc(bson.M{"status": "completed"}), false, true},
+		{"failed", doc(bson.M{"status": "failed"}), false, true},
+		{"processing with a live lease", doc(bson.M{"status": "processing", "workerID": "w1", "leaseExpiresAt": now.Add(time.Minute)}), false, false},
+		{"processing with a lease ending now", doc(bson.M{"status": "processing", "workerID": "w1", "leaseExpiresAt": now}), false, false},
+		{"processing with an expired lease", doc(bson.M{"status": "processing", "workerID": "w1", "leaseExpiresAt": now.Add(-time.Second)}), true, true},
+		{"processing without a lease", doc(bson.M{"status": "processing", "workerID": "w1"}), true, true},
+		{"cancel requested", doc(bson.M{"status": "cancel_requested"}), false, false},
+		{"cancelled", doc(bson.M{"status": "cancelled"}), false, false},
+		{"another document", bson.M{"_id": primitive.NewObjectID(), "status": "pending"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matches(t, claimFilter(id, now, false), tt.doc); got != tt.want {
+				t.Errorf("claimable = %v, want %v", got, tt.want)
+			}
+			if got := matches(t, claimFilter(id, now, true), tt.doc); got != tt.wantForce {
+				t.Errorf("claimable when forced = %v, want %v", got, tt.wantForce)
+			}
+		})
+	}
+}
+
+func TestStatusFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	doc := func(status, owner string) bson.M {
+		d := bson.M{"_id": id, "status": status}
+		if owner != "" {
+			d["workerID"] = owner
+		}
+		return d
+	}
+	tests := []struct {
+		name   string
+		owner  string
+		status string
+		doc    bson.M
+		want   bool
+	}{
+		{"owner completes", "w1", "completed", doc("processing", "w1"), true},
+		{"other worker completes", "w2", "completed", doc("processing", "w1"), false},
+		{"owner completes after losing the lease", "w1", "completed", doc("pending", "w1"), false},
+		{"owner completes a cancel request", "w1", "completed", doc("cancel_requested", "w1"), false},
+		{"owner fails", "w1", "failed", doc("processing", "w1"), true},
+		{"completion without an owner", "", "completed", doc("processing", "w1"), false},
+		{"owner cancels while processing", "w1", "cancelled", doc("processing", "w1"), true},
+		{"owner answers a cancel request", "w1", "cancelled", doc("cancel_requested", "w1"), true},
+		{"other worker answers a cancel request", "w2", "cancelled", doc("cancel_requested", "w1"), false},
+		{"owner cancels a finished job", "w1", "cancelled", doc("completed", "w1"), false},
+		{"unclaimed cancel request", "", "cancelled", doc("cancel_requested", ""), true},
+		{"unclaimed and already cancelled", "", "cancelled", doc("cancelled", ""), true},
+		{"unclaimed cancel of a pending job", "", "cancelled", doc("pending", ""), false},
+		{"unclaimed cancel of a processing job", "", "cancelled", doc("processing", "w1"), false},
+		{"another document", "w1", "completed", bson.M{"_id": primitive.NewObjectID(), "status": "processing", "workerID": "w1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matches(t, statusFilter(id, tt.owner, tt.status), tt.doc); got != tt.want {
+				t.Errorf("statusFilter(%q, %q) matches = %v, want %v", tt.owner, tt.status, got, tt.want)
+			}
+		})
+	}
+}
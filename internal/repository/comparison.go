@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"org-worker/internal/domain"
+	"org-worker/internal/retry"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Values of the compare filter.
+const (
+	// ComparePrevious compares with the period of the same length right
+	// before the report's; whole calendar months step back by months.
+	ComparePrevious = "previous"
+	// CompareLastYear compares with the same dates one year earlier.
+	CompareLastYear = "last_year"
+)
+
+// comparisonRange reads the compare filter and returns the period to compare
+// start..end with. mode is empty when no comparison was requested.
+func comparisonRange(filters map[string]interface{}, start, end time.Time) (mode string, prevStart, prevEnd time.Time, err error) {
+	mode, _ = filters["compare"].(string)
+	if mode == "" {
+		return "", time.Time{}, time.Time{}, nil
+	}
+	// end_date is inclusive and scheduled reports end one second before the
+	// next period starts.
+	next := end.Add(time.Second)
+	switch mode {
+	case CompareLastYear:
+		if wholeMonths(start, next) > 0 {
+			// Keeps February whole in leap years.
+			return mode, start.AddDate(-1, 0, 0), next.AddDate(-1, 0, 0).Add(-time.Second), nil
+		}
+		return mode, start.AddDate(-1, 0, 0), end.AddDate(-1, 0, 0), nil
+	case ComparePrevious:
+		if months := wholeMonths(start, next); months > 0 {
+			return mode, start.AddDate(0, -months, 0), start.Add(-time.Second), nil
+		}
+		return mode, start.Add(-next.Sub(start)), start.Add(-time.Second), nil
+	}
+	return "", time.Time{}, time.Time{}, retry.Permanent(fmt.Errorf("filter 'compare' tidak dikenal: %s", mode))
+}
+
+// wholeMonths returns how many calendar months lie between two month
+// starts, or 0 if start or end is not the first moment of a month.
+func wholeMonths(start, end time.Time) int {
+	isMonthStart := func(t time.Time) bool {
+		return t.Day() == 1 && t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+	}
+	if !isMonthStart(start) || !isMonthStart(end) {
+		return 0
+	}
+	return (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month())
+}
+
+// activityComparison counts the community activity summary metrics for an
+// earlier period, without the per-event details.
+func (r *ReportRepository) activityComparison(ctx context.Context, mode, communityName string, start, end time.Time) (*domain.ActivityComparison, error) {
+	cmp := &domain.ActivityComparison{Mode: mode, StartDate: start, EndDate: end}
+	var err error
+	cmp.NewMemberCount, err = r.db.Collection("users").CountDocuments(ctx, newMemberFilter(communityName, start, end))
+	if err != nil {
+		return nil, err
+	}
+	rawIDs, err := r.db.Collection("events").Distinct(ctx, "_id", eventFilter(communityName, start, end))
+	if err != nil {
+		return nil, err
+	}
+	eventIDs := make([]primitive.ObjectID, 0, len(rawIDs))
+	for _, raw := range rawIDs {
+		if id, ok := raw.(primitive.ObjectID); ok {
+			eventIDs = append(eventIDs, id)
+		}
+	}
+	cmp.EventsHeldCount = len(eventIDs)
+	cmp.ActiveMemberCount = r.countActiveMembers(ctx, eventIDs)
+	return cmp, nil
+}
+
+// impactComparison counts milestones by type for an earlier period.
+func (r *ReportRepository) impactComparison(ctx context.Context, mode, communityName string, start, end time.Time) (*domain.ImpactComparison, error) {
+	stats, err := r.milestoneStats(ctx, communityName, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ImpactComparison{Mode: mode, StartDate: start, EndDate: end, Stats: stats}, nil
+}
+
+// financialComparison computes the totals for an earlier period.
+func (r *ReportRepository) financialComparison(ctx context.Context, mode string, start, end time.Time) (*domain.FinancialComparison, error) {
+	prev, err := r.financialData(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.FinancialComparison{
+		Mode:             mode,
+		StartDate:        start,
+		EndDate:          end,
+		TotalIncome:      prev.TotalIncome,
+		TotalInKindValue: prev.TotalInKindValue,
+		TotalExpenses:    prev.TotalExpenses,
+		NetIncome:        prev.NetIncome,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"org-worker/internal/retry"
+)
+
+func TestComparisonRange(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	// endOf is the last second of the day before next, as scheduled reports
+	// set end_date.
+	endOf := func(next time.Time) time.Time { return next.Add(-time.Second) }
+	tests := []struct {
+		name       string
+		compare    string
+		start, end time.Time
+		wantStart  time.Time
+		wantEnd    time.Time
+	}{
+		{"previous month", ComparePrevious, day(2025, 5, 1), endOf(day(2025, 6, 1)), day(2025, 4, 1), endOf(day(2025, 5, 1))},
+		{"previous month of a short month", ComparePrevious, day(2025, 3, 1), endOf(day(2025, 4, 1)), day(2025, 2, 1), endOf(day(2025, 3, 1))},
+		{"previous month after a leap February", ComparePrevious, day(2024, 3, 1), endOf(day(2024, 4, 1)), day(2024, 2, 1), endOf(day(2024, 3, 1))},
+		{"previous quarter across a year", ComparePrevious, day(2025, 1, 1), endOf(day(2025, 4, 1)), day(2024, 10, 1), endOf(day(2025, 1, 1))},
+		{"previous week", ComparePrevious, day(2025, 5, 5), endOf(day(2025, 5, 12)), day(2025, 4, 28), endOf(day(2025, 5, 5))},
+		{"previous days ending mid-month", ComparePrevious, day(2025, 5, 10), endOf(day(2025, 5, 21)), day(2025, 4, 29), endOf(day(2025, 5, 10))},
+		{"last year month", CompareLastYear, day(2025, 5, 1), endOf(day(2025, 6, 1)), day(2024, 5, 1), endOf(day(2024, 6, 1))},
+		{"last year leap February", CompareLastYear, day(2025, 2, 1), endOf(day(2025, 3, 1)), day(2024, 2, 1), endOf(day(2024, 3, 1))},
+		{"last year from a leap February", CompareLastYear, day(2024, 2, 1), endOf(day(2024, 3, 1)), day(2023, 2, 1), endOf(day(2023, 3, 1))},
+		{"last year arbitrary days", CompareLastYear, day(2025, 5, 10), day(2025, 5, 20), day(2024, 5, 10), day(2024, 5, 20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, start, end, err := comparisonRange(map[string]interface{}{"compare": tt.compare}, tt.start, tt.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode != tt.compare {
+				t.Errorf("mode = %q, want %q", mode, tt.compare)
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("range = %s .. %s, want %s .. %s", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestComparisonRangeFilter(t *testing.T) {
+	start := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0).Add(-time.Second)
+
+	mode, _, _, err := comparisonRange(map[string]interface{}{}, start, end)
+	if err != nil || mode != "" {
+		t.Errorf("without compare: mode %q, err %v; want no comparison", mode, err)
+	}
+	_, _, _, err = comparisonRange(map[string]interface{}{"compare": "yesterday"}, start, end)
+	if err == nil || retry.IsRetryable(err) {
+		t.Errorf("unknown compare: err %v, want a permanent error", err)
+	}
+}
+
+func TestWholeMonths(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*3600)
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       int
+	}{
+		{"one month", time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), 1},
+		{"across a year", time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), 3},
+		{"leap February", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 1},
+		{"local month starts", time.Date(2025, 5, 1, 0, 0, 0, 0, jakarta), time.Date(2025, 6, 1, 0, 0, 0, 0, jakarta), 1},
+		{"start mid-month", time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), 0},
+		{"end not at midnight", time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 1, 0, 0, 1, 0, time.UTC), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wholeMonths(tt.start, tt.end); got != tt.want {
+				t.Errorf("wholeMonths(%s, %s) = %d, want %d", tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
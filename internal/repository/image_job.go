@@ -2,14 +2,17 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"org-worker/internal/domain"
+	"org-worker/internal/retry"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ImageJobRepository struct {
@@ -26,7 +29,7 @@ func (r *ImageJobRepository) FindByID(ctx context.Context, id string) (*domain.I
 	// 1. Konversi string ID ke ObjectID
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid object id: %v", err)
+		return nil, retry.Permanent(fmt.Errorf("invalid object id: %v", err))
 	}
 
 	// 2. Cari di database
@@ -34,7 +37,7 @@ func (r *ImageJobRepository) FindByID(ctx context.Context, id string) (*domain.I
 	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&job)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("image job not found")
+			return nil, retry.Permanent(fmt.Errorf("image job not found"))
 		}
 		return nil, err
 	}
@@ -42,16 +45,60 @@ func (r *ImageJobRepository) FindByID(ctx context.Context, id string) (*domain.I
 	return &job, nil
 }
 
-func (r *ImageJobRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+// Claim marks the image job as being processed by owner until leaseUntil.
+// See ReportRepository.ClaimReport for the possible errors.
+func (r *ImageJobRepository) Claim(ctx context.Context, id primitive.ObjectID, owner string, leaseUntil time.Time, force bool) error {
+	return claimDocument(ctx, r.collection, id, owner, leaseUntil, force)
+}
+
+// Release returns an image job claimed by owner to "pending".
+func (r *ImageJobRepository) Release(ctx context.Context, id primitive.ObjectID, owner string) error {
+	return releaseDocument(ctx, r.collection, id, owner)
+}
+
+// UpdateProgress overwrites the image job's progress sub-document.
+func (r *ImageJobRepository) UpdateProgress(ctx context.Context, id primitive.ObjectID, p domain.Progress) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"progress": p}})
+	return err
+}
+
+// GetStatus reads only the status field of an image job.
+func (r *ImageJobRepository) GetStatus(ctx context.Context, id primitive.ObjectID) (string, error) {
+	var job struct {
+		Status string `bson:"status"`
+	}
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}, options.FindOne().SetProjection(bson.M{"status": 1})).Decode(&job)
+	return job.Status, err
+}
+
+// UpdateStatus writes the outcome owner decided for the image job. It fails
+// with domain.ErrLeaseLost when owner no longer holds the job.
+func (r *ImageJobRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, owner string, fields bson.M) error {
 	fields["updatedAt"] = time.Now()
 
 	update := bson.M{
 		"$set": fields,
 	}
 
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
-	if err != nil {
+	status, _ := fields["status"].(string)
+	err := updateOwned(ctx, r.collection, statusFilter(id, owner, status), update)
+	if err != nil && !errors.Is(err, domain.ErrLeaseLost) {
 		return fmt.Errorf("failed to update image job: %v", err)
 	}
-	return nil
+	return err
+}
+
+// IncrementAttempts bumps the attempt counter and returns the new value.
+func (r *ImageJobRepository) IncrementAttempts(ctx context.Context, id primitive.ObjectID) (int, error) {
+	var job domain.ImageJobDoc
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"attempts": 1}, "$set": bson.M{"updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment image job attempts: %w", err)
+	}
+	return job.Attempts, nil
 }
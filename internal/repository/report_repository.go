@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"org-worker/internal/domain"
+	"org-worker/internal/retry"
 	"strings"
 	"time"
 
@@ -25,14 +26,16 @@ func (r *ReportRepository) GetReportByID(ctx context.Context, id string) (domain
 	var doc domain.ReportDoc
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return doc, err
+		return doc, retry.Permanent(err)
 	}
 	coll := r.db.Collection("reports")
 	err = coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&doc)
 	return doc, err
 }
 
-func (r *ReportRepository) UpdateReportStatus(ctx context.Context, id primitive.ObjectID, status, fileURL, errMsg string) error {
+// UpdateReportStatus writes the outcome owner decided for the report. It
+// fails with domain.ErrLeaseLost when owner no longer holds the report.
+func (r *ReportRepository) UpdateReportStatus(ctx context.Context, id primitive.ObjectID, owner, status, fileURL, errMsg string) error {
 	update := bson.M{
 		"$set": bson.M{
 			"status":    status,
@@ -41,27 +44,137 @@ func (r *ReportRepository) UpdateReportStatus(ctx context.Context, id primitive.
 			"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
 		},
 	}
-	_, err := r.db.Collection("reports").UpdateOne(ctx, bson.M{"_id": id}, update)
+	return updateOwned(ctx, r.db.Collection("reports"), statusFilter(id, owner, status), update)
+}
+
+// CompleteReport marks the report completed with the URL of every rendered
+// format; fileURL stays set for clients that only know one file. It fails
+// with domain.ErrLeaseLost when owner no longer holds the report.
+func (r *ReportRepository) CompleteReport(ctx context.Context, id primitive.ObjectID, owner, fileURL string, fileURLs map[string]string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":    "completed",
+			"fileURL":   fileURL,
+			"fileURLs":  fileURLs,
+			"errorMsg":  "",
+			"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
+		},
+	}
+	return updateOwned(ctx, r.db.Collection("reports"), ownedFilter(id, owner), update)
+}
+
+// ClaimReport marks the report as being processed by owner until leaseUntil.
+// It fails with domain.ErrNotClaimable for reports that are already done
+// (unless force is set) and *domain.LeaseHeldError while another worker
+// holds the lease.
+func (r *ReportRepository) ClaimReport(ctx context.Context, id primitive.ObjectID, owner string, leaseUntil time.Time, force bool) error {
+	return claimDocument(ctx, r.db.Collection("reports"), id, owner, leaseUntil, force)
+}
+
+// ReleaseReport returns a report claimed by owner to "pending".
+func (r *ReportRepository) ReleaseReport(ctx context.Context, id primitive.ObjectID, owner string) error {
+	return releaseDocument(ctx, r.db.Collection("reports"), id, owner)
+}
+
+// UpdateReportProgress overwrites the report's progress sub-document.
+func (r *ReportRepository) UpdateReportProgress(ctx context.Context, id primitive.ObjectID, p domain.Progress) error {
+	_, err := r.db.Collection("reports").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"progress": p}})
 	return err
 }
 
+// UpdateReportDelivery overwrites the report's email delivery sub-document.
+func (r *ReportRepository) UpdateReportDelivery(ctx context.Context, id primitive.ObjectID, d domain.Delivery) error {
+	_, err := r.db.Collection("reports").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"delivery": d}})
+	return err
+}
+
+// UpdateReportExports records the URLs of the report's raw-data exports.
+func (r *ReportRepository) UpdateReportExports(ctx context.Context, id primitive.ObjectID, urls map[string]string) error {
+	_, err := r.db.Collection("reports").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"exportURLs": urls}})
+	return err
+}
+
+// GetReportStatus reads only the status field of a report.
+func (r *ReportRepository) GetReportStatus(ctx context.Context, id primitive.ObjectID) (string, error) {
+	var doc struct {
+		Status string `bson:"status"`
+	}
+	err := r.db.Collection("reports").FindOne(ctx, bson.M{"_id": id}, options.FindOne().SetProjection(bson.M{"status": 1})).Decode(&doc)
+	return doc.Status, err
+}
+
+// GetReportType reads only the type field of a report.
+func (r *ReportRepository) GetReportType(ctx context.Context, id string) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", retry.Permanent(err)
+	}
+	var doc struct {
+		Type string `bson:"type"`
+	}
+	err = r.db.Collection("reports").FindOne(ctx, bson.M{"_id": objID}, options.FindOne().SetProjection(bson.M{"type": 1})).Decode(&doc)
+	return doc.Type, err
+}
+
+// IncrementAttempts bumps the attempt counter and returns the new value.
+func (r *ReportRepository) IncrementAttempts(ctx context.Context, id primitive.ObjectID) (int, error) {
+	var doc domain.ReportDoc
+	err := r.db.Collection("reports").FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$inc": bson.M{"attempts": 1},
+			"$set": bson.M{"updatedAt": primitive.NewDateTimeFromTime(time.Now())},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		return 0, err
+	}
+	return doc.Attempts, nil
+}
+
+// MarkReportRetrying records a failed attempt that will be retried at
+// nextRetryAt. It fails with domain.ErrLeaseLost when owner no longer holds
+// the report.
+func (r *ReportRepository) MarkReportRetrying(ctx context.Context, id primitive.ObjectID, owner, errMsg string, nextRetryAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":      "retrying",
+			"errorMsg":    errMsg,
+			"nextRetryAt": primitive.NewDateTimeFromTime(nextRetryAt),
+			"updatedAt":   primitive.NewDateTimeFromTime(time.Now()),
+		},
+	}
+	return updateOwned(ctx, r.db.Collection("reports"), ownedFilter(id, owner), update)
+}
+
 func (r *ReportRepository) GetCommunityActivityData(ctx context.Context, filters map[string]interface{}) (domain.CommunityActivityData, error) {
 	var data domain.CommunityActivityData
 	var err error
 
 	communityName, ok := filters["community_name"].(string)
 	if !ok {
-		return data, fmt.Errorf("filter 'community_name' hilang atau bukan string")
+		return data, retry.Permanent(fmt.Errorf("filter 'community_name' hilang atau bukan string"))
 	}
 	startDateStr, _ := filters["start_date"].(string)
 	endDateStr, _ := filters["end_date"].(string)
 	startDate, err := time.Parse(time.RFC3339, startDateStr)
 	if err != nil {
-		return data, fmt.Errorf("format 'start_date' salah: %w", err)
+		return data, retry.Permanent(fmt.Errorf("format 'start_date' salah: %w", err))
 	}
 	endDate, err := time.Parse(time.RFC3339, endDateStr)
 	if err != nil {
-		return data, fmt.Errorf("format 'end_date' salah: %w", err)
+		return data, retry.Permanent(fmt.Errorf("format 'end_date' salah: %w", err))
+	}
+
+	compareMode, prevStart, prevEnd, err := comparisonRange(filters, startDate, endDate)
+	if err != nil {
+		return data, err
+	}
+	interval, err := trendInterval(filters, startDate, endDate)
+	if err != nil {
+		return data, err
 	}
 
 	data.CommunityName = communityName
@@ -72,23 +185,9 @@ func (r *ReportRepository) GetCommunityActivityData(ctx context.Context, filters
 	eventsCollection := r.db.Collection("events")
 	attendancesCollection := r.db.Collection("attendances")
 
-	newMemberFilter := bson.M{
-		"communities": communityName,
-		"createdAt":   bson.M{"$gte": startDate, "$lte": endDate},
-	}
-	if communityName == "all" {
-		newMemberFilter = bson.M{"createdAt": bson.M{"$gte": startDate, "$lte": endDate}}
-	}
-	data.NewMemberCount, _ = usersCollection.CountDocuments(ctx, newMemberFilter)
+	data.NewMemberCount, _ = usersCollection.CountDocuments(ctx, newMemberFilter(communityName, startDate, endDate))
 
-	eventFilter := bson.M{
-		"community": communityName,
-		"date":      bson.M{"$gte": primitive.NewDateTimeFromTime(startDate), "$lte": primitive.NewDateTimeFromTime(endDate)},
-	}
-	if communityName == "all" {
-		delete(eventFilter, "community")
-	}
-	cursor, err := eventsCollection.Find(ctx, eventFilter)
+	cursor, err := eventsCollection.Find(ctx, eventFilter(communityName, startDate, endDate))
 	if err != nil {
 		return data, err
 	}
@@ -141,32 +240,7 @@ func (r *ReportRepository) GetCommunityActivityData(ctx context.Context, filters
 	for _, e := range events {
 		eventIDs = append(eventIDs, e.ID)
 	}
-	activeFilter := bson.M{
-		"eventID":       bson.M{"$in": eventIDs},
-		"attendee.type": "Member",
-	}
-	if len(eventIDs) == 0 {
-		data.ActiveMemberCount = 0
-	} else {
-		distinctUserIDs, _ := attendancesCollection.Distinct(ctx, "attendee.userID", activeFilter)
-		var activeCount int64
-		for _, raw := range distinctUserIDs {
-			switch v := raw.(type) {
-			case nil:
-				continue
-			case string:
-				if v == "" {
-					continue
-				}
-			case primitive.ObjectID:
-				if v == primitive.NilObjectID {
-					continue
-				}
-			}
-			activeCount++
-		}
-		data.ActiveMemberCount = activeCount
-	}
+	data.ActiveMemberCount = r.countActiveMembers(ctx, eventIDs)
 
 	tutorNameCache := make(map[string]string)
 	for _, event := range events {
@@ -224,9 +298,72 @@ func (r *ReportRepository) GetCommunityActivityData(ctx context.Context, filters
 			DocumentationURLs: docs,
 		})
 	}
+
+	data.TrendInterval = interval
+	if data.Trend, err = r.activityTrend(ctx, communityName, interval, startDate, endDate, data.EventDetails); err != nil {
+		return data, fmt.Errorf("gagal menghitung tren aktivitas: %w", err)
+	}
+	if compareMode != "" {
+		if data.Comparison, err = r.activityComparison(ctx, compareMode, communityName, prevStart, prevEnd); err != nil {
+			return data, fmt.Errorf("gagal menghitung periode pembanding: %w", err)
+		}
+	}
 	return data, nil
 }
 
+// newMemberFilter matches users who joined the community in the period.
+func newMemberFilter(communityName string, startDate, endDate time.Time) bson.M {
+	if communityName == "all" {
+		return bson.M{"createdAt": bson.M{"$gte": startDate, "$lte": endDate}}
+	}
+	return bson.M{
+		"communities": communityName,
+		"createdAt":   bson.M{"$gte": startDate, "$lte": endDate},
+	}
+}
+
+// eventFilter matches the community's events held in the period.
+func eventFilter(communityName string, startDate, endDate time.Time) bson.M {
+	filter := bson.M{
+		"community": communityName,
+		"date":      bson.M{"$gte": primitive.NewDateTimeFromTime(startDate), "$lte": primitive.NewDateTimeFromTime(endDate)},
+	}
+	if communityName == "all" {
+		delete(filter, "community")
+	}
+	return filter
+}
+
+// countActiveMembers counts the distinct members who attended any of the
+// events.
+func (r *ReportRepository) countActiveMembers(ctx context.Context, eventIDs []primitive.ObjectID) int64 {
+	if len(eventIDs) == 0 {
+		return 0
+	}
+	activeFilter := bson.M{
+		"eventID":       bson.M{"$in": eventIDs},
+		"attendee.type": "Member",
+	}
+	distinctUserIDs, _ := r.db.Collection("attendances").Distinct(ctx, "attendee.userID", activeFilter)
+	var activeCount int64
+	for _, raw := range distinctUserIDs {
+		switch v := raw.(type) {
+		case nil:
+			continue
+		case string:
+			if v == "" {
+				continue
+			}
+		case primitive.ObjectID:
+			if v == primitive.NilObjectID {
+				continue
+			}
+		}
+		activeCount++
+	}
+	return activeCount
+}
+
 type facetResult struct {
 	Total []struct {
 		Count int64 `bson:"count"`
@@ -242,7 +379,7 @@ func (r *ReportRepository) GetParticipantDemographicsData(ctx context.Context, f
 
 	communityName, ok := filters["community_name"].(string)
 	if !ok {
-		return data, fmt.Errorf("filter 'community_name' hilang atau bukan string")
+		return data, retry.Permanent(fmt.Errorf("filter 'community_name' hilang atau bukan string"))
 	}
 	data.CommunityName = communityName
 
@@ -285,7 +422,7 @@ func (r *ReportRepository) GetParticipantDemographicsData(ctx context.Context, f
 		return data, err
 	}
 	if len(results) == 0 {
-		return data, fmt.Errorf("tidak ada data demografi ditemukan")
+		return data, retry.Permanent(fmt.Errorf("tidak ada data demografi ditemukan"))
 	}
 	result := results[0]
 	if len(result.Total) > 0 {
@@ -304,25 +441,48 @@ func (r *ReportRepository) GetProgramImpactData(ctx context.Context, filters map
 
 	communityName, ok := filters["community_name"].(string)
 	if !ok {
-		return data, fmt.Errorf("filter 'community_name' hilang atau bukan string")
+		return data, retry.Permanent(fmt.Errorf("filter 'community_name' hilang atau bukan string"))
 	}
 	startDateStr, _ := filters["start_date"].(string)
 	endDateStr, _ := filters["end_date"].(string)
 	startDate, err := time.Parse(time.RFC3339, startDateStr)
 	if err != nil {
-		return data, fmt.Errorf("format 'start_date' salah: %w", err)
+		return data, retry.Permanent(fmt.Errorf("format 'start_date' salah: %w", err))
 	}
 	endDate, err := time.Parse(time.RFC3339, endDateStr)
 	if err != nil {
-		return data, fmt.Errorf("format 'end_date' salah: %w", err)
+		return data, retry.Permanent(fmt.Errorf("format 'end_date' salah: %w", err))
+	}
+
+	compareMode, prevStart, prevEnd, err := comparisonRange(filters, startDate, endDate)
+	if err != nil {
+		return data, err
 	}
 
 	data.CommunityName = communityName
 	data.StartDate = startDate
 	data.EndDate = endDate
 
-	milestonesCollection := r.db.Collection("milestones")
+	if data.Stats, err = r.milestoneStats(ctx, communityName, startDate, endDate); err != nil {
+		return data, err
+	}
+
+	highlights, err := r.fetchImpactHighlights(ctx, filters, communityName, startDate, endDate)
+	if err != nil {
+		return data, err
+	}
+	data.Highlights = highlights
+
+	if compareMode != "" {
+		if data.Comparison, err = r.impactComparison(ctx, compareMode, communityName, prevStart, prevEnd); err != nil {
+			return data, fmt.Errorf("gagal menghitung periode pembanding: %w", err)
+		}
+	}
+	return data, nil
+}
 
+// milestoneStats counts the milestones of the community's members by type.
+func (r *ReportRepository) milestoneStats(ctx context.Context, communityName string, startDate, endDate time.Time) ([]domain.MilestoneStat, error) {
 	matchStage := bson.M{
 		"date": bson.M{"$gte": primitive.NewDateTimeFromTime(startDate), "$lte": primitive.NewDateTimeFromTime(endDate)},
 	}
@@ -330,7 +490,7 @@ func (r *ReportRepository) GetProgramImpactData(ctx context.Context, filters map
 		usersCollection := r.db.Collection("users")
 		userCursor, err := usersCollection.Find(ctx, bson.M{"communities": communityName})
 		if err != nil {
-			return data, fmt.Errorf("gagal mengambil user untuk komunitas: %w", err)
+			return nil, fmt.Errorf("gagal mengambil user untuk komunitas: %w", err)
 		}
 		defer userCursor.Close(ctx)
 		type userRow struct {
@@ -338,15 +498,14 @@ func (r *ReportRepository) GetProgramImpactData(ctx context.Context, filters map
 		}
 		var userRows []userRow
 		if err = userCursor.All(ctx, &userRows); err != nil {
-			return data, fmt.Errorf("gagal decode user rows: %w", err)
+			return nil, fmt.Errorf("gagal decode user rows: %w", err)
 		}
 		userIDs := make([]primitive.ObjectID, 0, len(userRows))
 		for _, u := range userRows {
 			userIDs = append(userIDs, u.ID)
 		}
 		if len(userIDs) == 0 {
-			data.Stats = []domain.MilestoneStat{}
-			return data, nil
+			return []domain.MilestoneStat{}, nil
 		}
 		matchStage["userID"] = bson.M{"$in": userIDs}
 	}
@@ -360,23 +519,17 @@ func (r *ReportRepository) GetProgramImpactData(ctx context.Context, filters map
 		bson.D{{Key: "$sort", Value: bson.M{"count": -1}}},
 	}
 
-	cursor, err := milestonesCollection.Aggregate(ctx, pipeline)
+	cursor, err := r.db.Collection("milestones").Aggregate(ctx, pipeline)
 	if err != nil {
-		return data, err
+		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	if err = cursor.All(ctx, &data.Stats); err != nil {
-		return data, err
-	}
-
-	highlights, err := r.fetchImpactHighlights(ctx, filters, communityName, startDate, endDate)
-	if err != nil {
-		return data, err
+	var stats []domain.MilestoneStat
+	if err = cursor.All(ctx, &stats); err != nil {
+		return nil, err
 	}
-	data.Highlights = highlights
-
-	return data, nil
+	return stats, nil
 }
 
 func (r *ReportRepository) fetchImpactHighlights(ctx context.Context, filters map[string]interface{}, communityName string, startDate, endDate time.Time) ([]domain.ImpactHighlight, error) {
@@ -638,13 +791,32 @@ func (r *ReportRepository) GetFinancialSummaryData(ctx context.Context, filters
 	endDateStr, _ := filters["end_date"].(string)
 	startDate, err := time.Parse(time.RFC3339, startDateStr)
 	if err != nil {
-		return data, fmt.Errorf("format 'start_date' salah: %w", err)
+		return data, retry.Permanent(fmt.Errorf("format 'start_date' salah: %w", err))
 	}
 	endDate, err := time.Parse(time.RFC3339, endDateStr)
 	if err != nil {
-		return data, fmt.Errorf("format 'end_date' salah: %w", err)
+		return data, retry.Permanent(fmt.Errorf("format 'end_date' salah: %w", err))
+	}
+
+	compareMode, prevStart, prevEnd, err := comparisonRange(filters, startDate, endDate)
+	if err != nil {
+		return data, err
 	}
 
+	if data, err = r.financialData(ctx, startDate, endDate); err != nil {
+		return data, err
+	}
+	if compareMode != "" {
+		if data.Comparison, err = r.financialComparison(ctx, compareMode, prevStart, prevEnd); err != nil {
+			return data, fmt.Errorf("gagal menghitung periode pembanding: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// financialData aggregates donations and expenses for the period.
+func (r *ReportRepository) financialData(ctx context.Context, startDate, endDate time.Time) (domain.FinancialReportData, error) {
+	var data domain.FinancialReportData
 	data.StartDate = startDate
 	data.EndDate = endDate
 
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"org-worker/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ScheduleRepository struct {
+	schedules *mongo.Collection
+	reports   *mongo.Collection
+}
+
+func NewScheduleRepository(db *mongo.Database) *ScheduleRepository {
+	return &ScheduleRepository{
+		schedules: db.Collection("report_schedules"),
+		reports:   db.Collection("reports"),
+	}
+}
+
+// ListEnabled returns every schedule that is switched on.
+func (r *ScheduleRepository) ListEnabled(ctx context.Context) ([]domain.ReportSchedule, error) {
+	cursor, err := r.schedules.Find(ctx, bson.M{"enabled": true})
+	if err != nil {
+		return nil, err
+	}
+	var schedules []domain.ReportSchedule
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// AdvanceLastRun moves lastRunAt from prev to runAt once the run has been
+// enqueued. It returns false when the schedule was advanced by someone else
+// in the meantime.
+func (r *ScheduleRepository) AdvanceLastRun(ctx context.Context, id primitive.ObjectID, prev primitive.DateTime, runAt time.Time) (bool, error) {
+	filter := bson.M{"_id": id, "lastRunAt": prev}
+	if prev == 0 {
+		filter["lastRunAt"] = bson.M{"$exists": false}
+	}
+	result, err := r.schedules.UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{"lastRunAt": primitive.NewDateTimeFromTime(runAt)},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// RecordRun stores the outcome of a run on the schedule.
+func (r *ScheduleRepository) RecordRun(ctx context.Context, id, reportID primitive.ObjectID, errMsg string) error {
+	set := bson.M{"lastError": errMsg}
+	if !reportID.IsZero() {
+		set["lastReportID"] = reportID
+	}
+	_, err := r.schedules.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+// ScheduledReportID derives the reports _id of one scheduled run: the run
+// time as the ObjectID timestamp followed by a hash of the schedule ID. A run
+// that is fired again after a failure gets the same report.
+func ScheduledReportID(scheduleID primitive.ObjectID, runAt time.Time) primitive.ObjectID {
+	var id primitive.ObjectID
+	binary.BigEndian.PutUint32(id[:4], uint32(runAt.Unix()))
+	sum := sha256.Sum256(scheduleID[:])
+	copy(id[4:], sum[:8])
+	return id
+}
+
+// CreateReport inserts the pending reports document of the scheduled run at
+// runAt. It returns false if the run's report already exists, e.g. because
+// enqueueing it failed the first time.
+func (r *ScheduleRepository) CreateReport(ctx context.Context, scheduleID primitive.ObjectID, runAt time.Time, reportType string, filters map[string]interface{}) (primitive.ObjectID, bool, error) {
+	id := ScheduledReportID(scheduleID, runAt)
+	now := primitive.NewDateTimeFromTime(time.Now())
+	_, err := r.reports.InsertOne(ctx, bson.M{
+		"_id":        id,
+		"type":       reportType,
+		"status":     "pending",
+		"fileURL":    "",
+		"errorMsg":   "",
+		"filters":    filters,
+		"scheduleID": scheduleID,
+		"runAt":      primitive.NewDateTimeFromTime(runAt),
+		"createdAt":  now,
+		"updatedAt":  now,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return id, false, nil
+	}
+	return id, err == nil, err
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"org-worker/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetReportTemplate returns the report_templates document with the given
+// name, or nil when there is none.
+func (r *ReportRepository) GetReportTemplate(ctx context.Context, name string) (*domain.ReportTemplate, error) {
+	var tpl domain.ReportTemplate
+	err := r.db.Collection("report_templates").FindOne(ctx, bson.M{"name": name}).Decode(&tpl)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tpl, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"org-worker/internal/domain"
+	"org-worker/internal/retry"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Values of the trend_interval filter.
+const (
+	TrendWeekly  = "week"
+	TrendMonthly = "month"
+)
+
+// trendWeeklyLimit is the longest period bucketed by week when the
+// trend_interval filter is not set.
+const trendWeeklyLimit = 92 * 24 * time.Hour
+
+// trendInterval reads the trend_interval filter, defaulting to weeks for
+// periods up to about a quarter and months beyond that.
+func trendInterval(filters map[string]interface{}, start, end time.Time) (string, error) {
+	interval, _ := filters["trend_interval"].(string)
+	switch interval {
+	case TrendWeekly, TrendMonthly:
+		return interval, nil
+	case "":
+		if end.Sub(start) <= trendWeeklyLimit {
+			return TrendWeekly, nil
+		}
+		return TrendMonthly, nil
+	}
+	return "", retry.Permanent(fmt.Errorf("filter 'trend_interval' tidak dikenal: %s", interval))
+}
+
+// bucketStart returns the Monday or first of the month t falls in, in the
+// time zone of t.
+func bucketStart(t time.Time, interval string) time.Time {
+	if interval == TrendMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+func nextBucket(t time.Time, interval string) time.Time {
+	if interval == TrendMonthly {
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 7)
+}
+
+// activityTrend splits the period into buckets and counts the events held,
+// their attendance and the members who joined in each. Buckets follow the
+// time zone of start_date.
+func (r *ReportRepository) activityTrend(ctx context.Context, communityName, interval string, start, end time.Time, events []domain.EventDetail) ([]domain.ActivityBucket, error) {
+	var buckets []domain.ActivityBucket
+	index := make(map[int64]int)
+	for b := bucketStart(start, interval); !b.After(end); b = nextBucket(b, interval) {
+		index[b.Unix()] = len(buckets)
+		buckets = append(buckets, domain.ActivityBucket{Start: b})
+	}
+	bucketOf := func(t time.Time) (*domain.ActivityBucket, bool) {
+		i, ok := index[bucketStart(t.In(start.Location()), interval).Unix()]
+		if !ok {
+			return nil, false
+		}
+		return &buckets[i], true
+	}
+
+	for _, event := range events {
+		if b, ok := bucketOf(event.Date); ok {
+			b.EventsHeld++
+			b.Attendance += event.ParticipantCount
+		}
+	}
+
+	opts := options.Find().SetProjection(bson.M{"createdAt": 1})
+	cursor, err := r.db.Collection("users").Find(ctx, newMemberFilter(communityName, start, end), opts)
+	if err != nil {
+		return nil, err
+	}
+	var members []struct {
+		CreatedAt time.Time `bson:"createdAt"`
+	}
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if b, ok := bucketOf(m.CreatedAt); ok {
+			b.NewMembers++
+		}
+	}
+	return buckets, nil
+}
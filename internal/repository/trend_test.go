@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"org-worker/internal/retry"
+)
+
+func TestBucketStart(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*3600)
+	tests := []struct {
+		name     string
+		t        time.Time
+		interval string
+		want     time.Time
+	}{
+		{"Monday is its own week", time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC), TrendWeekly, time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{"Wednesday", time.Date(2025, 5, 7, 10, 0, 0, 0, time.UTC), TrendWeekly, time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{"Sunday belongs to the week before", time.Date(2025, 5, 11, 23, 59, 0, 0, time.UTC), TrendWeekly, time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{"week across a month", time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), TrendWeekly, time.Date(2025, 5, 26, 0, 0, 0, 0, time.UTC)},
+		{"week across a year", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), TrendWeekly, time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)},
+		{"local Sunday night", time.Date(2025, 5, 4, 23, 0, 0, 0, jakarta), TrendWeekly, time.Date(2025, 4, 28, 0, 0, 0, 0, jakarta)},
+		{"local Monday morning", time.Date(2025, 5, 5, 1, 0, 0, 0, jakarta), TrendWeekly, time.Date(2025, 5, 5, 0, 0, 0, 0, jakarta)},
+		{"month", time.Date(2025, 5, 31, 23, 0, 0, 0, time.UTC), TrendMonthly, time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC), TrendMonthly, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bucketStart(tt.t, tt.interval); !got.Equal(tt.want) || got.Location() != tt.want.Location() {
+				t.Errorf("bucketStart(%s, %s) = %s, want %s", tt.t, tt.interval, got, tt.want)
+			}
+		})
+	}
+}
+
+// A time stored in UTC must land in the bucket of its local date.
+func TestBucketStartConvertedToLocal(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*3600)
+	// Sunday 18:00 UTC is already Monday 01:00 in Jakarta.
+	stored := time.Date(2025, 5, 4, 18, 0, 0, 0, time.UTC)
+	want := time.Date(2025, 5, 5, 0, 0, 0, 0, jakarta)
+	if got := bucketStart(stored.In(jakarta), TrendWeekly); !got.Equal(want) {
+		t.Errorf("bucketStart = %s, want %s", got, want)
+	}
+}
+
+func TestNextBucket(t *testing.T) {
+	jan := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got, want := nextBucket(jan, TrendMonthly), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("nextBucket(month) = %s, want %s", got, want)
+	}
+	monday := time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)
+	if got, want := nextBucket(monday, TrendWeekly), time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("nextBucket(week) = %s, want %s", got, want)
+	}
+}
+
+func TestTrendInterval(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		filter  any
+		end     time.Time
+		want    string
+		wantErr bool
+	}{
+		{"month defaults to weeks", nil, start.AddDate(0, 1, 0).Add(-time.Second), TrendWeekly, false},
+		{"quarter defaults to weeks", nil, start.AddDate(0, 3, 0).Add(-time.Second), TrendWeekly, false},
+		{"year defaults to months", nil, start.AddDate(1, 0, 0).Add(-time.Second), TrendMonthly, false},
+		{"explicit month", "month", start.AddDate(0, 0, 7), TrendMonthly, false},
+		{"explicit week", "week", start.AddDate(1, 0, 0), TrendWeekly, false},
+		{"unknown", "day", start.AddDate(0, 0, 7), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters := map[string]interface{}{}
+			if tt.filter != nil {
+				filters["trend_interval"] = tt.filter
+			}
+			got, err := trendInterval(filters, start, tt.end)
+			if tt.wantErr {
+				if err == nil || retry.IsRetryable(err) {
+					t.Fatalf("trendInterval: err %v, want a permanent error", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("trendInterval = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Policy controls how often a task type is retried and how long to wait
+// between attempts.
+type Policy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff returns the delay before the next attempt: exponential in the
+// number of attempts already made, capped at MaxDelay (if set), with the
+// upper half randomized so retries from a burst of failures do not line up.
+func (p Policy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay) && delay < math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// CanRetry reports whether another attempt is allowed after err on the
+// given (1-based) attempt.
+func (p Policy) CanRetry(attempt int, err error) bool {
+	return attempt < p.MaxAttempts && IsRetryable(err)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one that will fail the same way on every attempt,
+// such as invalid filters or a missing tracking document.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsRetryable treats errors as transient unless they were marked permanent
+// or the tracking document no longer exists.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return true
+}
+
+// ScheduledError is returned by a handler that has already recorded the
+// failure on its tracking document and wants the job re-enqueued after Delay.
+type ScheduledError struct {
+	Err     error
+	Attempt int
+	Delay   time.Duration
+}
+
+func (e *ScheduledError) Error() string {
+	return fmt.Sprintf("attempt %d failed, retrying in %s: %v", e.Attempt, e.Delay, e.Err)
+}
+
+func (e *ScheduledError) Unwrap() error { return e.Err }
+
+// ExhaustedError is returned by a handler that has marked its tracking
+// document failed, either because the error is permanent or because the
+// policy ran out of attempts.
+type ExhaustedError struct {
+	Err     error
+	Attempt int
+}
+
+func (e *ExhaustedError) Error() string {
+	return fmt.Sprintf("giving up after attempt %d: %v", e.Attempt, e.Err)
+}
+
+func (e *ExhaustedError) Unwrap() error { return e.Err }
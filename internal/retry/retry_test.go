@@ -0,0 +1,84 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestPolicyBackoff(t *testing.T) {
+	policy := Policy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	tests := []struct {
+		name     string
+		policy   Policy
+		attempt  int
+		min, max time.Duration
+	}{
+		{"first attempt", policy, 1, 500 * time.Millisecond, time.Second},
+		{"attempt below one", policy, 0, 500 * time.Millisecond, time.Second},
+		{"second attempt doubles", policy, 2, time.Second, 2 * time.Second},
+		{"third attempt doubles again", policy, 3, 2 * time.Second, 4 * time.Second},
+		{"capped at MaxDelay", policy, 5, 5 * time.Second, 10 * time.Second},
+		{"far past the cap", policy, 60, 5 * time.Second, 10 * time.Second},
+		{"no MaxDelay", Policy{BaseDelay: time.Second}, 4, 4 * time.Second, 8 * time.Second},
+		{"no BaseDelay", Policy{MaxDelay: time.Second}, 3, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 200; i++ {
+				got := tt.policy.Backoff(tt.attempt)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("Backoff(%d) = %s, want within [%s, %s]", tt.attempt, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyBackoffIsJittered(t *testing.T) {
+	policy := Policy{BaseDelay: time.Second, MaxDelay: time.Minute}
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 50; i++ {
+		seen[policy.Backoff(3)] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("Backoff returned the same delay 50 times: %v", seen)
+	}
+}
+
+func TestPolicyCanRetry(t *testing.T) {
+	policy := Policy{MaxAttempts: 3}
+	transient := errors.New("connection reset")
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		want    bool
+	}{
+		{"transient error with attempts left", 1, transient, true},
+		{"last attempt", 3, transient, false},
+		{"permanent error", 1, Permanent(transient), false},
+		{"wrapped permanent error", 1, fmt.Errorf("fetch: %w", Permanent(transient)), false},
+		{"missing document", 1, mongo.ErrNoDocuments, false},
+		{"cancelled", 1, context.Canceled, false},
+		{"deadline exceeded", 1, context.DeadlineExceeded, true},
+		{"nil error", 1, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.CanRetry(tt.attempt, tt.err); got != tt.want {
+				t.Errorf("CanRetry(%d, %v) = %v, want %v", tt.attempt, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermanentNil(t *testing.T) {
+	if err := Permanent(nil); err != nil {
+		t.Errorf("Permanent(nil) = %v, want nil", err)
+	}
+}
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// periodRange resolves a relative period against the time a schedule fired.
+// The end is inclusive, matching how the report queries use end_date.
+func periodRange(period string, at time.Time) (time.Time, time.Time, error) {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	month := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, at.Location())
+	// Weeks start on Monday.
+	week := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	quarter := time.Date(at.Year(), ((at.Month()-1)/3)*3+1, 1, 0, 0, 0, 0, at.Location())
+	year := time.Date(at.Year(), 1, 1, 0, 0, 0, 0, at.Location())
+
+	var start, end time.Time
+	switch period {
+	case "previous_day":
+		start, end = day.AddDate(0, 0, -1), day
+	case "previous_week":
+		start, end = week.AddDate(0, 0, -7), week
+	case "previous_month":
+		start, end = month.AddDate(0, -1, 0), month
+	case "previous_quarter":
+		start, end = quarter.AddDate(0, -3, 0), quarter
+	case "previous_year":
+		start, end = year.AddDate(-1, 0, 0), year
+	case "current_month":
+		return month, at, nil
+	case "current_year":
+		return year, at, nil
+	case "last_7_days":
+		return at.AddDate(0, 0, -7), at, nil
+	case "last_30_days":
+		return at.AddDate(0, 0, -30), at, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown period %q", period)
+	}
+	return start, end.Add(-time.Second), nil
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodRange(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*3600)
+	at := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	// endOf is the last second before next, the inclusive end the report
+	// queries expect.
+	endOf := func(next time.Time) time.Time { return next.Add(-time.Second) }
+	tests := []struct {
+		name      string
+		period    string
+		at        time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"previous day on Jan 1", "previous_day", at(2025, 1, 1, 6), at(2024, 12, 31, 0), endOf(at(2025, 1, 1, 0))},
+		{"previous month on Jan 1", "previous_month", at(2025, 1, 1, 6), at(2024, 12, 1, 0), endOf(at(2025, 1, 1, 0))},
+		{"previous quarter on Jan 1", "previous_quarter", at(2025, 1, 1, 6), at(2024, 10, 1, 0), endOf(at(2025, 1, 1, 0))},
+		{"previous year on Jan 1", "previous_year", at(2025, 1, 1, 6), at(2024, 1, 1, 0), endOf(at(2025, 1, 1, 0))},
+		{"previous month on Dec 31", "previous_month", at(2025, 12, 31, 23), at(2025, 11, 1, 0), endOf(at(2025, 12, 1, 0))},
+		{"previous quarter on Dec 31", "previous_quarter", at(2025, 12, 31, 23), at(2025, 7, 1, 0), endOf(at(2025, 10, 1, 0))},
+		{"current year on Dec 31", "current_year", at(2025, 12, 31, 23), at(2025, 1, 1, 0), at(2025, 12, 31, 23)},
+		{"previous month after a leap February", "previous_month", at(2024, 3, 1, 6), at(2024, 2, 1, 0), endOf(at(2024, 3, 1, 0))},
+		{"previous week on a Monday", "previous_week", at(2025, 5, 5, 6), at(2025, 4, 28, 0), endOf(at(2025, 5, 5, 0))},
+		{"previous week on a Sunday", "previous_week", at(2025, 5, 11, 6), at(2025, 4, 28, 0), endOf(at(2025, 5, 5, 0))},
+		{"previous week across a year", "previous_week", at(2025, 1, 1, 6), at(2024, 12, 23, 0), endOf(at(2024, 12, 30, 0))},
+		{"current month", "current_month", at(2025, 5, 20, 6), at(2025, 5, 1, 0), at(2025, 5, 20, 6)},
+		{"last 7 days", "last_7_days", at(2025, 5, 3, 6), at(2025, 4, 26, 6), at(2025, 5, 3, 6)},
+		{"last 30 days", "last_30_days", at(2025, 3, 1, 6), at(2025, 1, 30, 6), at(2025, 3, 1, 6)},
+		{
+			"previous month in the schedule's zone", "previous_month",
+			time.Date(2025, 6, 1, 6, 0, 0, 0, jakarta),
+			time.Date(2025, 5, 1, 0, 0, 0, 0, jakarta), endOf(time.Date(2025, 6, 1, 0, 0, 0, 0, jakarta)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := periodRange(tt.period, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("periodRange(%q, %s) = %s – %s, want %s – %s", tt.period, tt.at, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestPeriodRangeUnknown(t *testing.T) {
+	if _, _, err := periodRange("next_month", time.Now()); err == nil {
+		t.Error("periodRange accepted an unknown period")
+	}
+}
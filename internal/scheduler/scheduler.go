@@ -0,0 +1,188 @@
+// Package scheduler turns report_schedules documents into generate_report
+// jobs on their cron schedule.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+
+	"org-worker/internal/domain"
+	"org-worker/internal/queue"
+	"org-worker/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scheduler checks the schedules every interval. Only the worker holding the
+// leader lock fires them. A run's report is created under an ID derived from
+// the schedule and run time and lastRunAt only advances once the job is
+// enqueued, so a failed run is fired again on the next tick without ever
+// creating a second report for it.
+type Scheduler struct {
+	repo      *repository.ScheduleRepository
+	client    *redis.Client
+	queueName string
+	interval  time.Duration
+	leader    *leader
+}
+
+func New(repo *repository.ScheduleRepository, client *redis.Client, queueName, owner string, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		repo:      repo,
+		client:    client,
+		queueName: queueName,
+		interval:  interval,
+		leader: &leader{
+			client: client,
+			key:    queueName + ":scheduler",
+			owner:  owner,
+			ttl:    3 * interval,
+		},
+	}
+}
+
+// Run fires due schedules until ctx is cancelled, then gives up leadership.
+func (s *Scheduler) Run(ctx context.Context, logger *slog.Logger) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	isLeader := false
+	for {
+		select {
+		case <-ctx.Done():
+			if isLeader {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				_ = s.leader.release(releaseCtx)
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+
+		acquired, err := s.leader.acquire(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Error("Failed to acquire scheduler lock", "err", err)
+			}
+			continue
+		}
+		if acquired != isLeader {
+			logger.Info("Scheduler leadership changed", "leader", acquired)
+			isLeader = acquired
+		}
+		if isLeader {
+			s.tick(ctx, logger, time.Now())
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context, logger *slog.Logger, now time.Time) {
+	schedules, err := s.repo.ListEnabled(ctx)
+	if err != nil {
+		logger.Error("Failed to load report schedules", "err", err)
+		return
+	}
+	for _, sched := range schedules {
+		if err := s.fire(ctx, logger, sched, now); err != nil {
+			logger.Error("Scheduled report failed", "scheduleID", sched.ID.Hex(), "name", sched.Name, "err", err)
+			_ = s.repo.RecordRun(ctx, sched.ID, primitive.NilObjectID, err.Error())
+		}
+	}
+}
+
+// fire enqueues one report if the schedule is due.
+func (s *Scheduler) fire(ctx context.Context, logger *slog.Logger, sched domain.ReportSchedule, now time.Time) error {
+	if sched.LastRunAt == 0 && sched.CreatedAt == 0 {
+		// Never ran and no creation time to count from: start the clock
+		// now so the first run is the next occurrence.
+		_, err := s.repo.AdvanceLastRun(ctx, sched.ID, 0, now)
+		return err
+	}
+	runAt, due, err := dueRun(sched, now)
+	if err != nil || !due {
+		return err
+	}
+	filters, err := runFilters(sched, runAt)
+	if err != nil {
+		return err
+	}
+
+	reportID, created, err := s.repo.CreateReport(ctx, sched.ID, runAt, sched.ReportType, filters)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	if !created {
+		// An earlier tick created it but did not get to advance lastRunAt.
+		// Pushing again is safe: only one delivery can claim the report.
+		logger.Info("Re-enqueueing report of an unfinished scheduled run", "scheduleID", sched.ID.Hex(), "reportID", reportID.Hex(), "runAt", runAt)
+	}
+	payload, _ := json.Marshal(domain.ReportJobPayload{
+		ReportID:   reportID.Hex(),
+		ReportType: sched.ReportType,
+		Filters:    filters,
+	})
+	data, _ := json.Marshal(domain.Job{TaskType: "generate_report", Payload: payload})
+	if err := queue.Push(s.client, ctx, s.queueName, string(data)); err != nil {
+		return fmt.Errorf("failed to enqueue report %s: %w", reportID.Hex(), err)
+	}
+	if advanced, err := s.repo.AdvanceLastRun(ctx, sched.ID, sched.LastRunAt, runAt); err != nil || !advanced {
+		// The next tick fires the same run again and finds its report.
+		return err
+	}
+	logger.Info("Scheduled report enqueued", "scheduleID", sched.ID.Hex(), "name", sched.Name, "reportID", reportID.Hex(), "runAt", runAt)
+	return s.repo.RecordRun(ctx, sched.ID, reportID, "")
+}
+
+// dueRun returns the scheduled time of the run due at now, counting from
+// lastRunAt or, before the first run, from createdAt. Runs missed while no
+// worker was up are collapsed into the most recent one. Until lastRunAt
+// advances every tick resolves to the same run.
+func dueRun(sched domain.ReportSchedule, now time.Time) (time.Time, bool, error) {
+	loc := time.Local
+	if sched.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(sched.Timezone); err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+	spec, err := cron.ParseStandard(sched.Cron)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid cron expression %q: %w", sched.Cron, err)
+	}
+
+	ref := sched.LastRunAt.Time()
+	if sched.LastRunAt == 0 {
+		ref = sched.CreatedAt.Time()
+	}
+	runAt := spec.Next(ref.In(loc))
+	if runAt.After(now) {
+		return time.Time{}, false, nil
+	}
+	for next := spec.Next(runAt); !next.After(now); next = spec.Next(next) {
+		runAt = next
+	}
+	return runAt, true, nil
+}
+
+// runFilters adds the run's date range, if the schedule has a period, to a
+// copy of the schedule's filters.
+func runFilters(sched domain.ReportSchedule, runAt time.Time) (map[string]interface{}, error) {
+	filters := maps.Clone(sched.Filters)
+	if filters == nil {
+		filters = map[string]interface{}{}
+	}
+	if sched.Period != "" {
+		start, end, err := periodRange(sched.Period, runAt)
+		if err != nil {
+			return nil, err
+		}
+		filters["start_date"] = start.Format(time.RFC3339)
+		filters["end_date"] = end.Format(time.RFC3339)
+	}
+	return filters, nil
+}
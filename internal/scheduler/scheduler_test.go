@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"org-worker/internal/domain"
+	"org-worker/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDueRun(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	dt := primitive.NewDateTimeFromTime
+	tests := []struct {
+		name    string
+		sched   domain.ReportSchedule
+		now     time.Time
+		want    time.Time
+		wantDue bool
+	}{
+		{
+			name:    "not due yet",
+			sched:   domain.ReportSchedule{Cron: "0 6 * * *", Timezone: "UTC", LastRunAt: dt(utc(2025, 5, 1, 6, 0))},
+			now:     utc(2025, 5, 2, 5, 59),
+			wantDue: false,
+		},
+		{
+			name:    "due",
+			sched:   domain.ReportSchedule{Cron: "0 6 * * *", Timezone: "UTC", LastRunAt: dt(utc(2025, 5, 1, 6, 0))},
+			now:     utc(2025, 5, 2, 6, 0),
+			want:    utc(2025, 5, 2, 6, 0),
+			wantDue: true,
+		},
+		{
+			name:    "missed runs collapse into the latest",
+			sched:   domain.ReportSchedule{Cron: "0 6 * * *", Timezone: "UTC", LastRunAt: dt(utc(2025, 5, 1, 6, 0))},
+			now:     utc(2025, 5, 4, 7, 0),
+			want:    utc(2025, 5, 4, 6, 0),
+			wantDue: true,
+		},
+		{
+			name:    "first run counts from createdAt",
+			sched:   domain.ReportSchedule{Cron: "0 6 1 * *", Timezone: "UTC", CreatedAt: dt(utc(2025, 4, 15, 0, 0))},
+			now:     utc(2025, 5, 1, 6, 30),
+			want:    utc(2025, 5, 1, 6, 0),
+			wantDue: true,
+		},
+		{
+			name:    "cron in the schedule's zone",
+			sched:   domain.ReportSchedule{Cron: "0 6 1 * *", Timezone: "Asia/Jakarta", LastRunAt: dt(time.Date(2025, 4, 1, 6, 0, 0, 0, jakarta))},
+			now:     utc(2025, 4, 30, 23, 0),
+			want:    time.Date(2025, 5, 1, 6, 0, 0, 0, jakarta),
+			wantDue: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, due, err := dueRun(tt.sched, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if due != tt.wantDue || (due && !got.Equal(tt.want)) {
+				t.Errorf("dueRun = %s, %v; want %s, %v", got, due, tt.want, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestDueRunInvalid(t *testing.T) {
+	for _, sched := range []domain.ReportSchedule{
+		{Cron: "every day", LastRunAt: primitive.NewDateTimeFromTime(time.Now().Add(-time.Hour))},
+		{Cron: "0 6 * * *", Timezone: "Mars/Olympus", LastRunAt: primitive.NewDateTimeFromTime(time.Now().Add(-time.Hour))},
+	} {
+		if _, _, err := dueRun(sched, time.Now()); err == nil {
+			t.Errorf("dueRun(%q, %q) accepted an invalid schedule", sched.Cron, sched.Timezone)
+		}
+	}
+}
+
+// A run whose push or lastRunAt update failed is fired again on the next
+// tick; it must resolve to the same run and therefore the same report.
+func TestRetriedRunReusesReportID(t *testing.T) {
+	sched := domain.ReportSchedule{
+		ID:        primitive.NewObjectID(),
+		Cron:      "0 6 * * 1",
+		Timezone:  "UTC",
+		Period:    "previous_week",
+		LastRunAt: primitive.NewDateTimeFromTime(time.Date(2025, 4, 28, 6, 0, 0, 0, time.UTC)),
+	}
+	first := time.Date(2025, 5, 5, 6, 0, 30, 0, time.UTC)
+	retried := first.Add(time.Hour)
+
+	runAt, due, err := dueRun(sched, first)
+	if err != nil || !due {
+		t.Fatalf("first tick: due %v, err %v", due, err)
+	}
+	again, due, err := dueRun(sched, retried)
+	if err != nil || !due {
+		t.Fatalf("retried tick: due %v, err %v", due, err)
+	}
+	if !again.Equal(runAt) {
+		t.Fatalf("retried tick resolved to %s, first to %s", again, runAt)
+	}
+	if repository.ScheduledReportID(sched.ID, again) != repository.ScheduledReportID(sched.ID, runAt) {
+		t.Error("retried tick derived a different report ID")
+	}
+
+	firstFilters, _ := runFilters(sched, runAt)
+	againFilters, _ := runFilters(sched, again)
+	if firstFilters["start_date"] != againFilters["start_date"] || firstFilters["end_date"] != againFilters["end_date"] {
+		t.Errorf("retried tick covers %v – %v, first %v – %v", againFilters["start_date"], againFilters["end_date"], firstFilters["start_date"], firstFilters["end_date"])
+	}
+
+	next, due, _ := dueRun(sched, runAt.AddDate(0, 0, 7))
+	if !due || repository.ScheduledReportID(sched.ID, next) == repository.ScheduledReportID(sched.ID, runAt) {
+		t.Error("the next week's run shares the report ID of this one")
+	}
+	other := primitive.NewObjectID()
+	if repository.ScheduledReportID(other, runAt) == repository.ScheduledReportID(sched.ID, runAt) {
+		t.Error("two schedules firing at the same time share a report ID")
+	}
+}
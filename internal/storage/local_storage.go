@@ -33,3 +33,19 @@ func (s *LocalFileStorage) Save(ctx context.Context, reportType, filename string
 	}
 	return fullPath, nil
 }
+
+// Ping checks that BasePath is an existing, writable directory without
+// creating it; Save creates the folders below it.
+func (s *LocalFileStorage) Ping(ctx context.Context) error {
+	info, err := os.Stat(s.BasePath)
+	if err != nil {
+		return fmt.Errorf("folder storage tidak dapat diakses: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("path storage bukan folder: %s", s.BasePath)
+	}
+	if info.Mode().Perm()&0200 == 0 {
+		return fmt.Errorf("folder storage tidak dapat ditulis: %s", s.BasePath)
+	}
+	return nil
+}
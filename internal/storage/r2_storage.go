@@ -56,6 +56,14 @@ func (s *R2Storage) Save(ctx context.Context, reportType, filename string, file
 	switch ext {
 	case ".pdf":
 		contentType = "application/pdf"
+	case ".xlsx":
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case ".html":
+		contentType = "text/html; charset=utf-8"
+	case ".zip":
+		contentType = "application/zip"
+	case ".json":
+		contentType = "application/json"
 	case ".webp":
 		contentType = "image/webp"
 	case ".jpg", ".jpeg":
@@ -76,3 +84,11 @@ func (s *R2Storage) Save(ctx context.Context, reportType, filename string, file
 	publicURL := fmt.Sprintf("%s/%s", s.PublicURL, objectKey)
 	return publicURL, nil
 }
+
+func (s *R2Storage) Ping(ctx context.Context) error {
+	_, err := s.Client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(s.Bucket)})
+	if err != nil {
+		return fmt.Errorf("bucket R2 tidak dapat diakses: %w", err)
+	}
+	return nil
+}
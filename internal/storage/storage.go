@@ -8,3 +8,9 @@ import (
 type StorageProvider interface {
 	Save(ctx context.Context, reportType, filename string, file *bytes.Buffer) (string, error)
 }
+
+// HealthChecker is implemented by providers that can verify they are
+// reachable without writing a file.
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+}
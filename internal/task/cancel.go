@@ -0,0 +1,122 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Statuses the API side writes (cancel_requested) and the worker answers
+// with (cancelled).
+const (
+	StatusCancelRequested = "cancel_requested"
+	StatusCancelled       = "cancelled"
+)
+
+// ErrCancelled is the cause attached to a job context when someone asked
+// for the job to be stopped.
+var ErrCancelled = errors.New("job cancelled by request")
+
+// IsCancelStatus reports whether a tracking document status means the job
+// must not run (any more).
+func IsCancelStatus(status string) bool {
+	return strings.EqualFold(status, StatusCancelRequested) || strings.EqualFold(status, StatusCancelled)
+}
+
+// CancelWatcher cancels running jobs when their tracking document is set to
+// cancel_requested or their document ID is published on the cancel channel.
+type CancelWatcher struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next uint64
+	jobs map[string]map[uint64]context.CancelCauseFunc
+}
+
+func NewCancelWatcher(interval time.Duration) *CancelWatcher {
+	return &CancelWatcher{interval: interval, jobs: make(map[string]map[uint64]context.CancelCauseFunc)}
+}
+
+// Listen subscribes to channel and cancels the job whose document ID arrives
+// as the message payload. It returns when ctx is cancelled.
+func (w *CancelWatcher) Listen(ctx context.Context, client *redis.Client, channel string, logger *slog.Logger) {
+	sub := client.Subscribe(ctx, channel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			id := strings.TrimSpace(msg.Payload)
+			if w.cancel(id) {
+				logger.Info("Cancelling job on request", "documentID", id)
+			}
+		}
+	}
+}
+
+// Watch derives a context that is cancelled with ErrCancelled once the job
+// identified by id is asked to stop. status is polled every interval to pick
+// up requests written straight to MongoDB. Call the returned func when the
+// job is done.
+func (w *CancelWatcher) Watch(ctx context.Context, id string, status func(context.Context) (string, error)) (context.Context, func()) {
+	jobCtx, cancel := context.WithCancelCause(ctx)
+
+	w.mu.Lock()
+	w.next++
+	key := w.next
+	if w.jobs[id] == nil {
+		w.jobs[id] = make(map[uint64]context.CancelCauseFunc)
+	}
+	w.jobs[id][key] = cancel
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				if current, err := status(jobCtx); err == nil && IsCancelStatus(current) {
+					cancel(ErrCancelled)
+					return
+				}
+			}
+		}
+	}()
+
+	return jobCtx, func() {
+		close(done)
+		w.mu.Lock()
+		delete(w.jobs[id], key)
+		if len(w.jobs[id]) == 0 {
+			delete(w.jobs, id)
+		}
+		w.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+func (w *CancelWatcher) cancel(id string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	cancels, ok := w.jobs[id]
+	for _, cancel := range cancels {
+		cancel(ErrCancelled)
+	}
+	return ok
+}
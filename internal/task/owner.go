@@ -0,0 +1,18 @@
+package task
+
+import "context"
+
+type ownerKey struct{}
+
+// WithOwner records the worker that claimed the job, so the handler can fence
+// its writes to the tracking document on the claim.
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// Owner returns the claim owner set by WithOwner, or "" while the job is not
+// claimed.
+func Owner(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerKey{}).(string)
+	return owner
+}
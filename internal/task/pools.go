@@ -0,0 +1,71 @@
+package task
+
+import (
+	"context"
+	"sort"
+)
+
+// Subtyper is implemented by handlers whose jobs split further for
+// concurrency purposes, e.g. generate_report by report type. It may read
+// the tracking document when the payload does not say.
+type Subtyper interface {
+	Subtype(ctx context.Context, payload any) string
+}
+
+// Pools caps how many jobs run at once per pool. A job belongs to the pool of
+// its task type ("generate_report") and, if the handler reports a subtype,
+// to "generate_report/<subtype>" as well; it needs a free slot in every
+// configured one. Jobs without a configured pool are only bound by the
+// worker-wide limit.
+type Pools struct {
+	slots map[string]chan struct{}
+}
+
+func NewPools(limits map[string]int) *Pools {
+	p := &Pools{slots: make(map[string]chan struct{})}
+	for key, limit := range limits {
+		if limit > 0 {
+			p.slots[key] = make(chan struct{}, limit)
+		}
+	}
+	return p
+}
+
+// Keys lists the configured pools in sorted order.
+func (p *Pools) Keys() []string {
+	keys := make([]string, 0, len(p.slots))
+	for key := range p.slots {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// InUse and Limit report a pool's occupied and total slots.
+func (p *Pools) InUse(key string) int { return len(p.slots[key]) }
+func (p *Pools) Limit(key string) int { return cap(p.slots[key]) }
+
+// TryAcquire takes a slot in every configured pool among keys without
+// waiting. On success the returned func gives them all back.
+func (p *Pools) TryAcquire(keys []string) (func(), bool) {
+	taken := make([]chan struct{}, 0, len(keys))
+	release := func() {
+		for _, slots := range taken {
+			<-slots
+		}
+	}
+	for _, key := range keys {
+		slots, ok := p.slots[key]
+		if !ok {
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+			taken = append(taken, slots)
+		default:
+			release()
+			return nil, false
+		}
+	}
+	return release, true
+}
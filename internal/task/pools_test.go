@@ -0,0 +1,80 @@
+package task
+
+import "testing"
+
+func TestPoolsTryAcquire(t *testing.T) {
+	tests := []struct {
+		name     string
+		limits   map[string]int
+		held     []string
+		keys     []string
+		want     bool
+		wantUsed map[string]int
+	}{
+		{
+			name:     "free pools",
+			limits:   map[string]int{"generate_report": 2, "generate_report/financial": 1},
+			keys:     []string{"generate_report", "generate_report/financial"},
+			want:     true,
+			wantUsed: map[string]int{"generate_report": 1, "generate_report/financial": 1},
+		},
+		{
+			name:     "unconfigured keys are ignored",
+			limits:   map[string]int{"generate_report": 1},
+			keys:     []string{"generate_report", "generate_report/impact"},
+			want:     true,
+			wantUsed: map[string]int{"generate_report": 1},
+		},
+		{
+			name:     "full first pool",
+			limits:   map[string]int{"generate_report": 1, "generate_report/financial": 1},
+			held:     []string{"generate_report"},
+			keys:     []string{"generate_report", "generate_report/financial"},
+			want:     false,
+			wantUsed: map[string]int{"generate_report": 1, "generate_report/financial": 0},
+		},
+		{
+			name:     "full later pool gives back the earlier slot",
+			limits:   map[string]int{"generate_report": 2, "generate_report/financial": 1},
+			held:     []string{"generate_report/financial"},
+			keys:     []string{"generate_report", "generate_report/financial"},
+			want:     false,
+			wantUsed: map[string]int{"generate_report": 0, "generate_report/financial": 1},
+		},
+		{
+			name:     "zero limit means no pool",
+			limits:   map[string]int{"process_image": 0},
+			keys:     []string{"process_image"},
+			want:     true,
+			wantUsed: map[string]int{"process_image": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPools(tt.limits)
+			for _, key := range tt.held {
+				if _, ok := p.TryAcquire([]string{key}); !ok {
+					t.Fatalf("could not take a slot in %s", key)
+				}
+			}
+			release, ok := p.TryAcquire(tt.keys)
+			if ok != tt.want {
+				t.Fatalf("TryAcquire(%v) ok = %v, want %v", tt.keys, ok, tt.want)
+			}
+			for key, want := range tt.wantUsed {
+				if got := p.InUse(key); got != want {
+					t.Errorf("InUse(%s) = %d, want %d", key, got, want)
+				}
+			}
+			if !ok {
+				return
+			}
+			release()
+			for _, key := range tt.keys {
+				if got := p.InUse(key); got != 0 {
+					t.Errorf("after release InUse(%s) = %d, want 0", key, got)
+				}
+			}
+		})
+	}
+}
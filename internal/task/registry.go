@@ -0,0 +1,143 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"org-worker/internal/domain"
+	"org-worker/internal/retry"
+)
+
+// Decoder turns a job's raw payload into the value its handler expects.
+type Decoder func(payload json.RawMessage) (any, error)
+
+// TaskHandler processes jobs of one task type.
+type TaskHandler interface {
+	Handle(ctx context.Context, logger *slog.Logger, payload any) error
+	// DocumentID returns the ID of the job's tracking document.
+	DocumentID(payload any) string
+	// Status reads the current status of the job's tracking document.
+	Status(ctx context.Context, payload any) (string, error)
+	// Claim atomically takes ownership of the tracking document until
+	// leaseUntil, failing with domain.ErrNotClaimable or
+	// *domain.LeaseHeldError when the job must not run now.
+	Claim(ctx context.Context, payload any, owner string, leaseUntil time.Time) error
+	// Release gives up a claim held by owner so the job can run again.
+	Release(ctx context.Context, payload any, owner string) error
+	// MarkStatus writes a status to the job's tracking document when the
+	// worker, not the handler, decides the outcome (e.g. shutdown). Like the
+	// handler's own final writes it is fenced on the claim owner in ctx (see
+	// WithOwner) and fails with domain.ErrLeaseLost once the claim is gone.
+	MarkStatus(ctx context.Context, payload any, status, errMsg string) error
+	// Result reads the outcome recorded on the job's tracking document.
+	Result(ctx context.Context, payload any) (domain.JobResult, error)
+}
+
+// JSONDecoder decodes the payload into a T.
+func JSONDecoder[T any]() Decoder {
+	return func(payload json.RawMessage) (any, error) {
+		var v T
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+type entry struct {
+	decode  Decoder
+	handler TaskHandler
+}
+
+// Registry maps task types to their decoder and handler.
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]entry)}
+}
+
+// Register adds a task type. Registering the same type twice is a
+// programming error and panics.
+func (r *Registry) Register(taskType string, decode Decoder, handler TaskHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.entries[taskType]; exists {
+		panic(fmt.Sprintf("task type %q registered twice", taskType))
+	}
+	r.entries[taskType] = entry{decode: decode, handler: handler}
+}
+
+func (r *Registry) lookup(taskType string) (entry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.entries[taskType]
+	return e, ok
+}
+
+// TaskTypes lists the registered task types in sorted order.
+func (r *Registry) TaskTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.entries))
+	for t := range r.entries {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Decode resolves the job's handler and payload. Unknown task types and
+// malformed payloads are permanent errors so they end up dead-lettered.
+func (r *Registry) Decode(job domain.Job) (TaskHandler, any, error) {
+	e, ok := r.lookup(job.TaskType)
+	if !ok {
+		return nil, nil, retry.Permanent(fmt.Errorf("unknown task type: %q", job.TaskType))
+	}
+	payload, err := e.decode(job.Payload)
+	if err != nil {
+		return nil, nil, retry.Permanent(fmt.Errorf("failed to decode %s payload: %w", job.TaskType, err))
+	}
+	return e.handler, payload, nil
+}
+
+// PoolKeys returns the concurrency pools a job belongs to, most specific
+// first. Jobs that cannot be decoded only get their task type.
+func (r *Registry) PoolKeys(ctx context.Context, job domain.Job) []string {
+	keys := []string{job.TaskType}
+	handler, payload, err := r.Decode(job)
+	if err != nil {
+		return keys
+	}
+	if subtyper, ok := handler.(Subtyper); ok {
+		if subtype := subtyper.Subtype(ctx, payload); subtype != "" {
+			keys = append([]string{job.TaskType + "/" + subtype}, keys...)
+		}
+	}
+	return keys
+}
+
+// Release forwards to the job's handler.
+func (r *Registry) Release(ctx context.Context, job domain.Job, owner string) error {
+	handler, payload, err := r.Decode(job)
+	if err != nil {
+		return err
+	}
+	return handler.Release(ctx, payload, owner)
+}
+
+// MarkStatus forwards to the job's handler.
+func (r *Registry) MarkStatus(ctx context.Context, job domain.Job, status, errMsg string) error {
+	handler, payload, err := r.Decode(job)
+	if err != nil {
+		return err
+	}
+	return handler.MarkStatus(ctx, payload, status, errMsg)
+}